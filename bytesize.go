@@ -0,0 +1,167 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes written with an SI or IEC unit, such as
+// 512KiB, 10MB or 1.5GiB. Being an integer, sizes compare and bound like
+// any other number:
+//
+//	if cfg.CacheSize > 2*env.GiB {
+//		return errors.New("cache size must be at most 2GiB")
+//	}
+type ByteSize uint64
+
+// SI units, powers of 1000.
+const (
+	B  ByteSize = 1
+	KB ByteSize = 1000 * B
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+)
+
+// IEC units, powers of 1024.
+const (
+	KiB ByteSize = 1 << (10 * (iota + 1))
+	MiB
+	GiB
+	TiB
+	PiB
+	EiB
+)
+
+// byteUnits lists the units by name, largest first within each system.
+var byteUnits = []struct {
+	name string
+	size ByteSize
+}{
+	{"EiB", EiB}, {"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	{"EB", EB}, {"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+	{"B", B},
+}
+
+// byteSizePattern matches the values accepted by ParseByteSize
+const byteSizePattern = `^[0-9]+(\.[0-9]+)?\s*([kKmMgGtTpPeE][iI]?)?[bB]?$`
+
+// ParseByteSize parses a decimal number followed by an optional unit, which
+// is a byte count by default. Units are matched case-insensitively: KB, MB,
+// GB, TB, PB and EB are powers of 1000 and KiB, MiB, GiB, TiB, PiB and EiB
+// powers of 1024. A fraction is allowed as long as the size is a whole
+// number of bytes, so 1.5KiB is 1536 but 1.5B is an error.
+func ParseByteSize(s string) (ByteSize, error) {
+	number := strings.TrimRightFunc(s, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	unit := strings.TrimSpace(s[len(number):])
+	if number == "" {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	size := B
+	if unit != "" {
+		found := false
+		for _, u := range byteUnits {
+			if strings.EqualFold(unit, u.name) || (u.name != "B" && strings.EqualFold(unit, strings.TrimSuffix(u.name, "B"))) {
+				size, found = u.size, true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown unit %q in byte size %q", unit, s)
+		}
+	}
+
+	whole, fraction, _ := strings.Cut(number, ".")
+	if whole == "" || strings.Contains(fraction, ".") {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	n, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	hi, v := bits.Mul64(n, uint64(size))
+	if hi != 0 {
+		return 0, fmt.Errorf("byte size %q %w", s, strconv.ErrRange)
+	}
+
+	// add fraction*size, which must be a whole number of bytes
+	fraction = strings.TrimRight(fraction, "0")
+	if fraction != "" {
+		numerator, err := strconv.ParseUint(fraction, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid byte size %q", s)
+		}
+		denominator := uint64(1)
+		for range fraction {
+			denominator *= 10
+		}
+		hi, lo := bits.Mul64(numerator, uint64(size))
+		if hi >= denominator {
+			return 0, fmt.Errorf("byte size %q %w", s, strconv.ErrRange)
+		}
+		bytes, rem := bits.Div64(hi, lo, denominator)
+		if rem != 0 {
+			return 0, fmt.Errorf("byte size %q is not a whole number of bytes", s)
+		}
+		var carry uint64
+		v, carry = bits.Add64(v, bytes, 0)
+		if carry != 0 {
+			return 0, fmt.Errorf("byte size %q %w", s, strconv.ErrRange)
+		}
+	}
+	return ByteSize(v), nil
+}
+
+// String returns the shortest exact form of b, a whole number of the unit
+// giving the fewest digits, such as 512KiB, 10MB or 1500B.
+func (b ByteSize) String() string {
+	best := strconv.FormatUint(uint64(b), 10) + "B"
+	if b == 0 {
+		return best
+	}
+	for _, u := range byteUnits {
+		if b%u.size != 0 {
+			continue
+		}
+		if s := strconv.FormatUint(uint64(b/u.size), 10) + u.name; len(s) < len(best) {
+			best = s
+		}
+	}
+	return best
+}
+
+// UnmarshalEnvironmentValue implements Unmarshaler.
+func (b *ByteSize) UnmarshalEnvironmentValue(data string) error {
+	v, err := ParseByteSize(data)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// MarshalEnvironmentValue implements Marshaler.
+func (b ByteSize) MarshalEnvironmentValue() (string, error) {
+	return b.String(), nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"flag"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value    string
+		expected ByteSize
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"100B", 100},
+		{"512KiB", 512 * KiB},
+		{"10MB", 10 * MB},
+		{"1.5GiB", 3 * GiB / 2},
+		{"1.5KB", 1500},
+		{"2.50 mb", 2500 * KB},
+		{"64k", 64 * KB},
+		{"64Ki", 64 * KiB},
+		{"18446744073709551615B", math.MaxUint64},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.value)
+		if err != nil {
+			t.Errorf("%s: Expected no error but got '%s'", tt.value, err)
+		} else if got != tt.expected {
+			t.Errorf("%s: Expected %d but got %d", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestParseByteSizeInvalid(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"":         "invalid byte size",
+		"MB":       "invalid byte size",
+		".5MB":     "invalid byte size",
+		"1.2.3B":   "invalid byte size",
+		"-1KB":     "invalid byte size",
+		"10QB":     `unknown unit "QB"`,
+		"1.5B":     "not a whole number of bytes",
+		"1.0001KB": "not a whole number of bytes",
+		"16EiB":    "out of range",
+		"18.5EB":   "out of range",
+	}
+	for value, reason := range tests {
+		if _, err := ParseByteSize(value); err == nil || !strings.Contains(err.Error(), reason) {
+			t.Errorf("%s: Expected error '%s' but got '%v'", value, reason, err)
+		}
+	}
+	if _, err := ParseByteSize("16EiB"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("Expected a range error but got '%v'", err)
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	t.Parallel()
+	tests := map[ByteSize]string{
+		0:              "0B",
+		1:              "1B",
+		1500:           "1500B",
+		512 * KiB:      "512KiB",
+		10 * MB:        "10MB",
+		3 * GiB / 2:    "1536MiB",
+		1000 * KiB:     "1024KB",
+		math.MaxUint64: "18446744073709551615B",
+	}
+	for size, expected := range tests {
+		if got := size.String(); got != expected {
+			t.Errorf("%d: Expected '%s' but got '%s'", uint64(size), expected, got)
+		}
+		if parsed, err := ParseByteSize(size.String()); err != nil || parsed != size {
+			t.Errorf("%d: Expected '%s' to parse back but got %d, %v", uint64(size), size, parsed, err)
+		}
+	}
+}
+
+func TestUnmarshalByteSize(t *testing.T) {
+	t.Parallel()
+	type ByteSizeStruct struct {
+		Cache  ByteSize  `env:"CACHE_SIZE,default=64MiB"`
+		Upload ByteSize  `env:"UPLOAD_LIMIT"`
+		Buffer *ByteSize `env:"BUFFER_SIZE"`
+	}
+
+	var byteSizeStruct ByteSizeStruct
+	flags, err := RegisterFlags(&byteSizeStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := flags.Parse([]string{"-buffer-size=4KiB"}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := Unmarshal(flags, EnvSet{"UPLOAD_LIMIT": "1.5GB"}, &byteSizeStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if byteSizeStruct.Cache != 64*MiB || byteSizeStruct.Upload != 1500*MB || byteSizeStruct.Buffer == nil || *byteSizeStruct.Buffer != 4*KiB {
+		t.Errorf("Unexpected sizes %+v", byteSizeStruct)
+	}
+
+	es, err := Marshal(&byteSizeStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := EnvSet{"CACHE_SIZE": "64MiB", "UPLOAD_LIMIT": "1500MB", "BUFFER_SIZE": "4KiB"}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected '%v' but got '%v'", expected, es)
+	}
+
+	err = Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), EnvSet{"UPLOAD_LIMIT": "lots"}, &byteSizeStruct)
+	if err == nil || !strings.Contains(err.Error(), `invalid byte size "lots"`) {
+		t.Errorf("Expected an invalid byte size error but got '%v'", err)
+	}
+}
+
+func TestByteSizePattern(t *testing.T) {
+	t.Parallel()
+	pattern := regexp.MustCompile(byteSizePattern)
+	for _, value := range []string{"1024", "512KiB", "1.5GiB", "10 MB", "64k"} {
+		if !pattern.MatchString(value) {
+			t.Errorf("%s: Expected the pattern to match", value)
+		}
+	}
+	for _, value := range []string{"", "MB", ".5KB", "-1KB", "10QB"} {
+		if pattern.MatchString(value) {
+			t.Errorf("%s: Expected the pattern not to match", value)
+		}
+	}
+}
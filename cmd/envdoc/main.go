@@ -0,0 +1,142 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Envdoc renders the reference documentation of a config struct tagged with
+// `env` field tags. It is meant to be run by go generate from the directory
+// of the package declaring the struct:
+//
+//	//go:generate go run github.com/TubbyStubby/go-env-flags/cmd/envdoc -type Config -o README.md
+//
+// If the output file contains the lines "<!-- envdoc:start -->" and
+// "<!-- envdoc:end -->", only the text between them is replaced, so the
+// reference can live inside a hand written README. A missing file is created
+// with the reference between the markers, so that go generate can run again;
+// an existing file without the markers is an error rather than overwritten.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/template"
+
+	"github.com/TubbyStubby/go-env-flags/internal/gorun"
+)
+
+const (
+	startMarker = "<!-- envdoc:start -->"
+	endMarker   = "<!-- envdoc:end -->"
+)
+
+var program = template.Must(template.New("main").Parse(`package main
+
+import (
+	"fmt"
+	"os"
+
+	env "github.com/TubbyStubby/go-env-flags"
+	pkg "{{.ImportPath}}"
+)
+
+func main() {
+	if err := env.{{.Func}}(os.Stdout, new(pkg.{{.Type}})); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("envdoc: ")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: envdoc -type T [-format markdown|text] [-o file]")
+		flag.PrintDefaults()
+	}
+
+	var (
+		typeName = flag.String("type", "", "name of the config struct type; required")
+		format   = flag.String("format", "markdown", "output format: markdown or text")
+		output   = flag.String("o", "", "output file; defaults to standard output")
+	)
+	flag.Parse()
+
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	funcs := map[string]string{"markdown": "GenerateMarkdown", "text": "GenerateText"}
+	fn, ok := funcs[*format]
+	if !ok {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	pkg, err := gorun.Load(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var src bytes.Buffer
+	err = program.Execute(&src, map[string]string{
+		"ImportPath": pkg.ImportPath,
+		"Func":       fn,
+		"Type":       *typeName,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var doc bytes.Buffer
+	if err := gorun.Run(pkg, src.Bytes(), &doc); err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(doc.Bytes())
+		return
+	}
+	if err := writeOutput(*output, doc.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeOutput writes doc to path, replacing only the text between the envdoc
+// markers of the existing file. A missing file is created with the markers
+// around doc.
+func writeOutput(path string, doc []byte) error {
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		existing, err = []byte(startMarker+"\n"+endMarker+"\n"), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	start := bytes.Index(existing, []byte(startMarker))
+	end := bytes.Index(existing, []byte(endMarker))
+	if start < 0 || end < start {
+		return fmt.Errorf("%s exists but has no %s and %s lines to put the reference between; add them or remove the file", path, startMarker, endMarker)
+	}
+
+	var b bytes.Buffer
+	b.Write(existing[:start+len(startMarker)])
+	b.WriteString("\n")
+	b.Write(doc)
+	b.Write(existing[end:])
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteOutputMissing(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "README.md")
+	if err := writeOutput(path, []byte("first\n")); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	// the markers are kept, so a second run replaces the reference
+	if err := writeOutput(path, []byte("second\n")); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := startMarker + "\nsecond\n" + endMarker + "\n"
+	if string(data) != expected {
+		t.Errorf("Expected file to be\n%s\nbut got\n%s", expected, data)
+	}
+}
+
+func TestWriteOutputMarkers(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "README.md")
+	existing := "# App\n\nIntro.\n\n" + startMarker + "\nstale\n" + endMarker + "\n\n## License\n"
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutput(path, []byte("| Field |\n")); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# App\n\nIntro.\n\n" + startMarker + "\n| Field |\n" + endMarker + "\n\n## License\n"
+	if string(data) != expected {
+		t.Errorf("Expected file to be\n%s\nbut got\n%s", expected, data)
+	}
+}
+
+func TestWriteOutputNoMarkers(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"none":     "# Hand written\n",
+		"reversed": endMarker + "\n" + startMarker + "\n",
+		"no end":   startMarker + "\n",
+	}
+	for name, existing := range tests {
+		path := filepath.Join(t.TempDir(), "README.md")
+		if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err := writeOutput(path, []byte("doc\n"))
+		if err == nil || !strings.Contains(err.Error(), "add them or remove the file") {
+			t.Errorf("%s: Expected an error for the missing markers but got '%v'", name, err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != existing {
+			t.Errorf("%s: Expected the file to be left untouched but got\n%s", name, data)
+		}
+	}
+}
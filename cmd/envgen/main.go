@@ -0,0 +1,135 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Envgen generates reflection free UnmarshalEnv, MarshalEnv and
+// RegisterEnvFlags methods for config structs tagged with `env` field tags.
+// env.Unmarshal, env.Marshal and env.RegisterFlags call them when present.
+// It is meant to be run by go generate from the directory of the package
+// declaring the structs:
+//
+//	//go:generate go run github.com/TubbyStubby/go-env-flags/cmd/envgen -type Config,Secrets -o config_env.go
+//
+// The generated file must be regenerated whenever the structs change.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/TubbyStubby/go-env-flags/internal/gorun"
+)
+
+var program = template.Must(template.New("main").Parse(`package main
+
+import (
+	"fmt"
+	"os"
+
+	env "github.com/TubbyStubby/go-env-flags"
+	pkg "{{.ImportPath}}"
+)
+
+func main() {
+	err := env.GenerateCode(os.Stdout, {{printf "%q" .Name}}{{range .Types}}, new(pkg.{{.}}){{end}})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("envgen: ")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: envgen -type T[,T...] [-o file]")
+		flag.PrintDefaults()
+	}
+
+	var (
+		typeNames = flag.String("type", "", "comma separated names of the config struct types; required")
+		output    = flag.String("o", "", "output file; defaults to standard output")
+	)
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	pkg, err := gorun.Load(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var src bytes.Buffer
+	err = program.Execute(&src, map[string]interface{}{
+		"ImportPath": pkg.ImportPath,
+		"Name":       pkg.Name,
+		"Types":      strings.Split(*typeNames, ","),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the generated file must not be compiled into the throwaway program,
+	// or a stale version could break the build it is meant to fix
+	var hide []string
+	if *output != "" {
+		hide = append(hide, *output)
+	}
+
+	var code bytes.Buffer
+	if err := gorun.Run(pkg, src.Bytes(), &code, hide...); err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(code.Bytes())
+		return
+	}
+	if err := writeOutput(*output, code.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeOutput replaces path with code through a temporary file renamed over
+// it, so the previous version is kept until the new one is fully written.
+func writeOutput(path string, code []byte) error {
+	// the suffix keeps the temporary file out of the package sources
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(code); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
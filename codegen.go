@@ -0,0 +1,635 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	goformat "go/format"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envImportPath is the import path of this package, used by generated code.
+const envImportPath = "github.com/TubbyStubby/go-env-flags"
+
+// ErrUnsupportedGenerate returned when GenerateCode is given a type it
+// cannot generate code for.
+var ErrUnsupportedGenerate = errors.New("type is not supported by the code generator")
+
+// GenerateCode writes a Go source file of package pkgName to w, holding
+// UnmarshalEnv, MarshalEnv and RegisterEnvFlags methods for the struct types
+// pointed to by values. The methods behave like Unmarshal, Marshal and
+// RegisterFlags without using reflection, and those functions call them when
+// present. All types must be named and declared in the same package, the one
+// the file is written for.
+//
+// Fields whose type the generated code cannot handle make GenerateCode fail
+// with ErrUnsupportedGenerate, rather than generating code that behaves
+// differently from the reflective path. If a value is nil or not a pointer
+// to a struct, GenerateCode returns an ErrInvalidValue.
+func GenerateCode(w io.Writer, pkgName string, values ...interface{}) error {
+	g := &generator{imports: map[string]string{
+		"flag":        "flag",
+		envImportPath: "env",
+	}}
+
+	for _, v := range values {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+			return ErrInvalidValue
+		}
+
+		t := rv.Type().Elem()
+		if t.Name() == "" {
+			return fmt.Errorf("%w: anonymous struct", ErrUnsupportedGenerate)
+		}
+		if g.pkgPath == "" {
+			g.pkgPath = t.PkgPath()
+		} else if g.pkgPath != t.PkgPath() {
+			return fmt.Errorf("%w: %s is not declared in %s", ErrUnsupportedGenerate, t, g.pkgPath)
+		}
+		if g.pkgPath == envImportPath {
+			return fmt.Errorf("%w: %s is declared in package env", ErrUnsupportedGenerate, t)
+		}
+
+		if err := g.genType(t); err != nil {
+			return err
+		}
+	}
+
+	var src strings.Builder
+	src.WriteString("// Code generated by envgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", pkgName)
+	importPaths := make([]string, 0, len(g.imports))
+	for importPath := range g.imports {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Slice(importPaths, func(i, j int) bool {
+		if isStdImport(importPaths[i]) != isStdImport(importPaths[j]) {
+			return isStdImport(importPaths[i])
+		}
+		return importPaths[i] < importPaths[j]
+	})
+	for i, importPath := range importPaths {
+		// standard library packages first, in their own group
+		if i > 0 && isStdImport(importPaths[i-1]) && !isStdImport(importPath) {
+			src.WriteString("\n")
+		}
+		if name := g.imports[importPath]; name != path.Base(importPath) {
+			fmt.Fprintf(&src, "\t%s %q\n", name, importPath)
+		} else {
+			fmt.Fprintf(&src, "\t%q\n", importPath)
+		}
+	}
+	src.WriteString(")\n")
+	src.WriteString(g.body.String())
+
+	formatted, err := goformat.Source([]byte(src.String()))
+	if err != nil {
+		return fmt.Errorf("formatting generated code: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// isStdImport reports whether importPath belongs to the standard library,
+// whose paths have no dot in their first element.
+func isStdImport(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// generator accumulates the methods of the types passed to GenerateCode.
+type generator struct {
+	// pkgPath is the import path of the package the code is generated for
+	pkgPath string
+	// imports maps import paths used by the body to their package names
+	imports map[string]string
+	body    strings.Builder
+	// tmp numbers the temporary variables of the current method
+	tmp int
+}
+
+func (g *generator) genType(t reflect.Type) error {
+	p, err := planOf(t)
+	if err != nil {
+		return err
+	}
+	fields := p.fields
+	for _, f := range fields {
+		if !f.Exported {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Path, ErrUnexportedField)
+		}
+	}
+	// the structs behind interface fields are only known at run time
+	if len(p.ifaces) > 0 {
+		return fmt.Errorf("%s.%s: %w: interface field", t.Name(), p.ifaces[0].Path, ErrUnsupportedGenerate)
+	}
+	for _, ptr := range p.ptrs {
+		if ptr.Type.Name() == "" {
+			return fmt.Errorf("%s.%s: %w: pointer to anonymous struct", t.Name(), ptr.Path, ErrUnsupportedGenerate)
+		}
+	}
+
+	b := &g.body
+	g.tmp = 0
+	fmt.Fprintf(b, "\n// UnmarshalEnv implements env.EnvUnmarshaler.\n")
+	fmt.Fprintf(b, "func (v *%s) UnmarshalEnv(flags *flag.FlagSet, es env.EnvSet) error {\n", t.Name())
+	b.WriteString("r := env.NewResolver(flags, es)\n")
+	for _, ptr := range p.ptrs {
+		conds := append(ptrGuards(t, ptr.Index), "v."+ptr.Path+" == nil")
+		if !ptr.AllocAlways {
+			var present []string
+			for _, f := range fields[ptr.first:ptr.end] {
+				present = append(present, fmt.Sprintf("r.Present(%q, %#v, %#v)", f.Tag.Flag, f.KeyFlags, f.Tag.Keys))
+			}
+			if len(present) == 0 {
+				// only kept for the pointers below it, never allocated
+				continue
+			}
+			conds = append(conds, "("+strings.Join(present, " || ")+")")
+		}
+		fmt.Fprintf(b, "\n// %s\n", ptr.Path)
+		fmt.Fprintf(b, "if %s {\n", strings.Join(conds, " && "))
+		fmt.Fprintf(b, "v.%s = new(%s)\n", ptr.Path, g.typeExpr(ptr.Type))
+		b.WriteString("}\n")
+	}
+	for _, f := range fields {
+		fmt.Fprintf(b, "\n// %s\n", f.Path)
+		// fields below a nil pointer are neither defaulted nor required
+		guards := ptrGuards(t, f.Index)
+		if len(guards) > 0 {
+			fmt.Fprintf(b, "if %s {\n", strings.Join(guards, " && "))
+		}
+		fmt.Fprintf(b, "if value, source, ok, err := r.Lookup(%q, %#v, %#v, %q, %t, %t); err != nil {\n", f.Tag.Flag, f.KeyFlags, f.Tag.Keys, f.Tag.Default, f.Tag.Required, !f.Tag.NoExpand)
+		b.WriteString("return err\n")
+		b.WriteString("} else if ok {\n")
+		if err := g.emitSet(b, "v."+f.Path, f.Type, "value", f.Tag); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Path, err)
+		}
+		fmt.Fprintf(b, "r.Consume(source, %#v)\n", f.Tag.Keys)
+		b.WriteString("}\n")
+		if len(guards) > 0 {
+			b.WriteString("}\n")
+		}
+	}
+	b.WriteString("return nil\n}\n")
+
+	g.tmp = 0
+	fmt.Fprintf(b, "\n// MarshalEnv implements env.EnvMarshaler.\n")
+	fmt.Fprintf(b, "func (v *%s) MarshalEnv() (env.EnvSet, error) {\n", t.Name())
+	b.WriteString("es := make(env.EnvSet)\n")
+	for _, f := range fields {
+		src := "v." + f.Path
+		fmt.Fprintf(b, "\n// %s\n", f.Path)
+		conds := ptrGuards(t, f.Index)
+		switch f.Type.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			conds = append(conds, src+" != nil")
+		}
+		if len(conds) > 0 {
+			fmt.Fprintf(b, "if %s {\n", strings.Join(conds, " && "))
+		} else {
+			b.WriteString("{\n")
+		}
+		b.WriteString("var value string\n")
+		if err := g.emitFormat(b, "value", src, f.Type, f.Tag, true); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Path, err)
+		}
+		if !f.Tag.NoExpand {
+			fmt.Fprintf(b, "value = %s.ReplaceAll(value, \"$\", \"$$\")\n", g.use("strings"))
+		}
+		for _, envKey := range f.Tag.Keys {
+			fmt.Fprintf(b, "es[%q] = value\n", envKey)
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteString("return es, nil\n}\n")
+
+	fmt.Fprintf(b, "\n// RegisterEnvFlags implements env.EnvFlagRegisterer.\n")
+	fmt.Fprintf(b, "func (v *%s) RegisterEnvFlags(flags *flag.FlagSet) error {\n", t.Name())
+	for _, f := range fields {
+		for _, flagName := range f.Flags {
+			fmt.Fprintf(b, "flags.String(%q, %q, %q)\n", flagName, f.Tag.Default, f.description())
+		}
+	}
+	b.WriteString("return nil\n}\n")
+
+	return nil
+}
+
+// ptrGuards returns the conditions under which the field at index below the
+// struct type t can be reached from v: that each pointer to struct on the
+// way is non-nil.
+func ptrGuards(t reflect.Type, index []int) []string {
+	var guards []string
+	expr := "v"
+	for _, i := range index[:len(index)-1] {
+		sf := t.Field(i)
+		expr += "." + sf.Name
+		t = sf.Type
+		if t.Kind() == reflect.Ptr {
+			guards = append(guards, expr+" != nil")
+			t = t.Elem()
+		}
+	}
+	return guards
+}
+
+// emitSet writes statements that parse the string expression value into the
+// addressable expression dst of type t, mirroring set.
+func (g *generator) emitSet(b *strings.Builder, dst string, t reflect.Type, value string, envTag tag) error {
+	sliceSeparator := envTag.separator()
+
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr && t.Implements(unmarshalType) {
+		p := g.newTmp()
+		fmt.Fprintf(b, "%s := new(%s)\n", p, g.typeExpr(t.Elem()))
+		fmt.Fprintf(b, "if err := %s.UnmarshalEnvironmentValue(%s); err != nil {\nreturn err\n}\n", p, value)
+		fmt.Fprintf(b, "%s = %s\n", dst, p)
+		return nil
+	}
+	if !isPtr && reflect.PointerTo(t).Implements(unmarshalType) {
+		fmt.Fprintf(b, "if err := (&%s).UnmarshalEnvironmentValue(%s); err != nil {\nreturn err\n}\n", dst, value)
+		return nil
+	}
+	if enumOf(t) != nil {
+		x := g.newTmp()
+		fmt.Fprintf(b, "%s, err := env.EnumValue[%s](%s)\n", x, g.typeExpr(t), value)
+		b.WriteString("if err != nil {\nreturn err\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, x)
+		return nil
+	}
+	if isStdType(t) {
+		g.emitSetStdType(b, dst, t, value, envTag)
+		return nil
+	}
+	if isBytes(t) {
+		x := g.newTmp()
+		fmt.Fprintf(b, "%s, err := env.DecodeBytes(%s, %q, %d)\n", x, value, envTag.Encoding, envTag.Len)
+		b.WriteString("if err != nil {\nreturn err\n}\n")
+		if t.Name() != "" {
+			x = g.typeExpr(t) + "(" + x + ")"
+		}
+		fmt.Fprintf(b, "%s = %s\n", dst, x)
+		return nil
+	}
+	if isByteArray(t) {
+		x := g.newTmp()
+		fmt.Fprintf(b, "%s, err := env.DecodeBytes(%s, %q, %d)\n", x, value, envTag.Encoding, t.Len())
+		b.WriteString("if err != nil {\nreturn err\n}\n")
+		fmt.Fprintf(b, "copy(%s[:], %s)\n", dst, x)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		p := g.newTmp()
+		fmt.Fprintf(b, "%s := new(%s)\n", p, g.typeExpr(t.Elem()))
+		if err := g.emitSet(b, "(*"+p+")", t.Elem(), value, envTag); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s = %s\n", dst, p)
+	case reflect.String:
+		fmt.Fprintf(b, "%s = %s\n", dst, g.convert(t, "string", value))
+	case reflect.Bool:
+		x := g.newTmp()
+		fmt.Fprintf(b, "%s, err := %s.ParseBool(%s)\n", x, g.use("strconv"), value)
+		b.WriteString("if err != nil {\nreturn err\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, g.convert(t, "bool", x))
+	case reflect.Float32, reflect.Float64:
+		x := g.newTmp()
+		fmt.Fprintf(b, "%s, err := %s.ParseFloat(%s, %d)\n", x, g.use("strconv"), value, t.Bits())
+		b.WriteString("if err != nil {\nreturn err\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, g.convert(t, "float64", x))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x := g.newTmp()
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			fmt.Fprintf(b, "%s, err := %s.ParseDuration(%s)\n", x, g.use("time"), value)
+		} else {
+			fmt.Fprintf(b, "%s, err := env.ParseInt(%s, %s)\n", x, value, g.bitSize(t))
+		}
+		b.WriteString("if err != nil {\nreturn err\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, g.convert(t, "int64", x))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x := g.newTmp()
+		fmt.Fprintf(b, "%s, err := env.ParseUint(%s, %s)\n", x, value, g.bitSize(t))
+		b.WriteString("if err != nil {\nreturn err\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, g.convert(t, "uint64", x))
+	case reflect.Slice:
+		parts, s, i, part := g.newTmp(), g.newTmp(), g.newTmp(), g.newTmp()
+		fmt.Fprintf(b, "if %s == \"\" {\n%s = make(%s, 0)\n} else {\n", value, dst, g.typeExpr(t))
+		fmt.Fprintf(b, "%s := %s.Split(%s, %q)\n", parts, g.use("strings"), value, sliceSeparator)
+		fmt.Fprintf(b, "%s := make(%s, len(%s))\n", s, g.typeExpr(t), parts)
+		fmt.Fprintf(b, "for %s, %s := range %s {\n", i, part, parts)
+		if err := g.emitSet(b, s+"["+i+"]", t.Elem(), part, envTag); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+		fmt.Fprintf(b, "%s = %s\n}\n", dst, s)
+	case reflect.Array:
+		parts, a, i, part := g.newTmp(), g.newTmp(), g.newTmp(), g.newTmp()
+		fmt.Fprintf(b, "var %s []string\n", parts)
+		fmt.Fprintf(b, "if %s != \"\" {\n%s = %s.Split(%s, %q)\n}\n", value, parts, g.use("strings"), value, sliceSeparator)
+		fmt.Fprintf(b, "if len(%s) != %d {\nreturn %s.Errorf(\"expected %d elements, got %%d\", len(%s))\n}\n", parts, t.Len(), g.use("fmt"), t.Len(), parts)
+		fmt.Fprintf(b, "var %s %s\n", a, g.typeExpr(t))
+		fmt.Fprintf(b, "for %s, %s := range %s {\n", i, part, parts)
+		if err := g.emitSet(b, a+"["+i+"]", t.Elem(), part, envTag); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, a)
+	case reflect.Map:
+		m, entry, kv, k, e := g.newTmp(), g.newTmp(), g.newTmp(), g.newTmp(), g.newTmp()
+		fmt.Fprintf(b, "%s := make(%s)\n", m, g.typeExpr(t))
+		fmt.Fprintf(b, "if %s != \"\" {\n", value)
+		fmt.Fprintf(b, "for _, %s := range %s.Split(%s, %q) {\n", entry, g.use("strings"), value, sliceSeparator)
+		fmt.Fprintf(b, "%s := %s.SplitN(%s, \"=\", 2)\n", kv, g.use("strings"), entry)
+		fmt.Fprintf(b, "if len(%s) != 2 {\nreturn %s.Errorf(\"map entry %%q must have format key=value\", %s)\n}\n", kv, g.use("fmt"), entry)
+		fmt.Fprintf(b, "var %s %s\n", k, g.typeExpr(t.Key()))
+		if err := g.emitSet(b, k, t.Key(), kv+"[0]", envTag); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "var %s %s\n", e, g.typeExpr(t.Elem()))
+		if err := g.emitSet(b, e, t.Elem(), kv+"[1]", envTag); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s[%s] = %s\n", m, k, e)
+		b.WriteString("}\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, m)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedGenerate, t)
+	}
+	return nil
+}
+
+// emitFormat writes statements that format the expression src of type t into
+// the string variable dst, mirroring format. addressable tells whether src
+// is addressable, which makes pointer receiver Marshalers available.
+func (g *generator) emitFormat(b *strings.Builder, dst, src string, t reflect.Type, envTag tag, addressable bool) error {
+	sliceSeparator := envTag.separator()
+
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		fmt.Fprintf(b, "if %s != nil {\n", src)
+	}
+	if t.Implements(marshalType) || (!isPtr && addressable && reflect.PointerTo(t).Implements(marshalType)) {
+		s := g.newTmp()
+		fmt.Fprintf(b, "%s, err := %s.MarshalEnvironmentValue()\n", s, src)
+		b.WriteString("if err != nil {\nreturn nil, err\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, s)
+		if isPtr {
+			b.WriteString("}\n")
+		}
+		return nil
+	}
+	if enumOf(t) != nil && !isPtr {
+		// like format, values without a name fall back to their type
+		name := g.newTmp()
+		fmt.Fprintf(b, "if %s, ok := env.EnumName(%s); ok {\n%s = %s\n} else {\n", name, src, dst, name)
+		defer b.WriteString("}\n")
+	}
+	if isStdType(t) {
+		g.emitFormatStdType(b, dst, src, t, envTag)
+		if isPtr {
+			b.WriteString("}\n")
+		}
+		return nil
+	}
+	if isBytes(t) {
+		if t.Name() != "" {
+			src = "[]byte(" + src + ")"
+		}
+		fmt.Fprintf(b, "%s = env.EncodeBytes(%s, %q)\n", dst, src, envTag.Encoding)
+		return nil
+	}
+	if isByteArray(t) {
+		// slicing needs an addressable array
+		a := g.newTmp()
+		fmt.Fprintf(b, "%s := %s\n", a, src)
+		fmt.Fprintf(b, "%s = env.EncodeBytes(%s[:], %q)\n", dst, a, envTag.Encoding)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if err := g.emitFormat(b, dst, "(*"+src+")", t.Elem(), envTag, true); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+	case reflect.String:
+		fmt.Fprintf(b, "%s = %s\n", dst, toBase(t, "string", src))
+	case reflect.Bool:
+		fmt.Fprintf(b, "%s = %s.FormatBool(%s)\n", dst, g.use("strconv"), toBase(t, "bool", src))
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprintf(b, "%s = %s.FormatFloat(%s, 'g', -1, %d)\n", dst, g.use("strconv"), toBase(t, "float64", src), t.Bits())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			fmt.Fprintf(b, "%s = %s.Duration(%s).String()\n", dst, g.use("time"), src)
+		} else {
+			fmt.Fprintf(b, "%s = %s.FormatInt(%s, 10)\n", dst, g.use("strconv"), toBase(t, "int64", src))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fmt.Fprintf(b, "%s = %s.FormatUint(%s, 10)\n", dst, g.use("strconv"), toBase(t, "uint64", src))
+	case reflect.Slice, reflect.Array:
+		parts, i := g.newTmp(), g.newTmp()
+		fmt.Fprintf(b, "%s := make([]string, len(%s))\n", parts, src)
+		fmt.Fprintf(b, "for %s := range %s {\n", i, src)
+		if err := g.emitFormat(b, parts+"["+i+"]", src+"["+i+"]", t.Elem(), envTag, true); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+		joined := g.newTmp()
+		fmt.Fprintf(b, "%s, err := env.JoinValues(%s, %q)\n", joined, parts, sliceSeparator)
+		b.WriteString("if err != nil {\nreturn nil, err\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, joined)
+	case reflect.Map:
+		keys, values, k, e, ks, es := g.newTmp(), g.newTmp(), g.newTmp(), g.newTmp(), g.newTmp(), g.newTmp()
+		fmt.Fprintf(b, "%s := make([]string, 0, len(%s))\n", keys, src)
+		fmt.Fprintf(b, "%s := make([]string, 0, len(%s))\n", values, src)
+		fmt.Fprintf(b, "for %s, %s := range %s {\n", k, e, src)
+		fmt.Fprintf(b, "var %s, %s string\n", ks, es)
+		// like the reflective path, map keys and values are not addressable
+		if err := g.emitFormat(b, ks, k, t.Key(), envTag, false); err != nil {
+			return err
+		}
+		if err := g.emitFormat(b, es, e, t.Elem(), envTag, false); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s = append(%s, %s)\n", keys, keys, ks)
+		fmt.Fprintf(b, "%s = append(%s, %s)\n", values, values, es)
+		b.WriteString("}\n")
+		joined := g.newTmp()
+		fmt.Fprintf(b, "%s, err := env.JoinEntries(%s, %s, %q)\n", joined, keys, values, sliceSeparator)
+		b.WriteString("if err != nil {\nreturn nil, err\n}\n")
+		fmt.Fprintf(b, "%s = %s\n", dst, joined)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedGenerate, t)
+	}
+	return nil
+}
+
+// emitSetStdType writes statements that parse value into dst of the
+// standard library type t, mirroring setStdType.
+func (g *generator) emitSetStdType(b *strings.Builder, dst string, t reflect.Type, value string, envTag tag) {
+	switch t {
+	case addrType, prefixType:
+		fmt.Fprintf(b, "if err := (&%s).UnmarshalText([]byte(%s)); err != nil {\nreturn err\n}\n", dst, value)
+		return
+	}
+
+	x := g.newTmp()
+	switch t {
+	case timeType:
+		layout := envTag.Layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		fmt.Fprintf(b, "%s, err := %s.Parse(%q, %s)\n", x, g.use("time"), layout, value)
+	case locationType:
+		fmt.Fprintf(b, "%s, err := %s.LoadLocation(%s)\n", x, g.use("time"), value)
+	case urlType:
+		fmt.Fprintf(b, "%s, err := %s.Parse(%s)\n", x, g.use("net/url"), value)
+		x = "*" + x
+	case regexpType:
+		fmt.Fprintf(b, "%s, err := %s.Compile(%s)\n", x, g.use("regexp"), value)
+	}
+	b.WriteString("if err != nil {\nreturn err\n}\n")
+	fmt.Fprintf(b, "%s = %s\n", dst, x)
+}
+
+// emitFormatStdType writes statements that format src of the standard
+// library type t into dst, mirroring formatStdType.
+func (g *generator) emitFormatStdType(b *strings.Builder, dst, src string, t reflect.Type, envTag tag) {
+	switch t {
+	case timeType:
+		layout := envTag.Layout
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		fmt.Fprintf(b, "%s = %s.Format(%q)\n", dst, src, layout)
+	case urlType:
+		// String has a pointer receiver and src may not be addressable
+		u := g.newTmp()
+		fmt.Fprintf(b, "%s := %s\n", u, src)
+		fmt.Fprintf(b, "%s = %s.String()\n", dst, u)
+	case addrType, prefixType:
+		x := g.newTmp()
+		fmt.Fprintf(b, "%s, err := %s.MarshalText()\n", x, src)
+		b.WriteString("if err != nil {\nreturn nil, err\n}\n")
+		fmt.Fprintf(b, "%s = string(%s)\n", dst, x)
+	default:
+		// *time.Location and *regexp.Regexp
+		fmt.Fprintf(b, "%s = %s.String()\n", dst, src)
+	}
+}
+
+// newTmp returns a fresh temporary variable name.
+func (g *generator) newTmp() string {
+	g.tmp++
+	return fmt.Sprintf("x%d", g.tmp)
+}
+
+// use records the import of a standard library package and returns its name.
+func (g *generator) use(importPath string) string {
+	return g.importName(importPath)
+}
+
+// convert returns expr, of the predeclared type named base, converted to t
+// unless t is that predeclared type.
+func (g *generator) convert(t reflect.Type, base, expr string) string {
+	if t.PkgPath() == "" && t.Name() == base {
+		return expr
+	}
+	return g.typeExpr(t) + "(" + expr + ")"
+}
+
+// bitSize returns the expression of the size in bits of the integer type t,
+// strconv.IntSize for int and uint whose size depends on the platform.
+func (g *generator) bitSize(t reflect.Type) string {
+	if t.Kind() == reflect.Int || t.Kind() == reflect.Uint {
+		return g.use("strconv") + ".IntSize"
+	}
+	return strconv.Itoa(t.Bits())
+}
+
+// toBase returns expr, of type t, converted to the predeclared type named
+// base unless t is that type.
+func toBase(t reflect.Type, base, expr string) string {
+	if t.PkgPath() == "" && t.Name() == base {
+		return expr
+	}
+	return base + "(" + expr + ")"
+}
+
+// typeExpr returns the Go expression of t as seen from the generated file,
+// recording the imports it needs.
+func (g *generator) typeExpr(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" || t.PkgPath() == g.pkgPath {
+			return t.Name()
+		}
+		return g.importName(t.PkgPath()) + "." + t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + g.typeExpr(t.Elem())
+	case reflect.Slice:
+		return "[]" + g.typeExpr(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), g.typeExpr(t.Elem()))
+	case reflect.Map:
+		return "map[" + g.typeExpr(t.Key()) + "]" + g.typeExpr(t.Elem())
+	default:
+		// anonymous structs and the like are only reached through field
+		// selectors, never named in the generated code
+		return t.String()
+	}
+}
+
+// importName returns the package name used for importPath, adding the import
+// under a unique name if needed.
+func (g *generator) importName(importPath string) string {
+	if name, ok := g.imports[importPath]; ok {
+		return name
+	}
+
+	base := path.Base(importPath)
+	base = strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, base)
+
+	// identifiers of the generated methods must not be shadowed
+	taken := map[string]bool{"v": true, "r": true, "es": true, "value": true, "source": true, "ok": true, "err": true}
+	for _, name := range g.imports {
+		taken[name] = true
+	}
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.imports[importPath] = name
+	return name
+}
@@ -0,0 +1,1069 @@
+// Code generated by envgen. DO NOT EDIT.
+
+package env_test
+
+import (
+	"flag"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/TubbyStubby/go-env-flags"
+)
+
+// UnmarshalEnv implements env.EnvUnmarshaler.
+func (v *GenStruct) UnmarshalEnv(flags *flag.FlagSet, es env.EnvSet) error {
+	r := env.NewResolver(flags, es)
+
+	// Cache
+	if v.Cache == nil && (r.Present("", []string{"cache-url"}, []string{"CACHE_URL"}) || r.Present("", []string{"cache-ttl"}, []string{"CACHE_TTL"}) || r.Present("", []string{"pool-size"}, []string{"POOL_SIZE"})) {
+		v.Cache = new(GenCache)
+	}
+
+	// Cache.Pool
+	if v.Cache != nil && v.Cache.Pool == nil {
+		v.Cache.Pool = new(GenPool)
+	}
+
+	// Home
+	if value, source, ok, err := r.Lookup("", []string{"home"}, []string{"HOME"}, "", true, true); err != nil {
+		return err
+	} else if ok {
+		v.Home = value
+		r.Consume(source, []string{"HOME"})
+	}
+
+	// Name
+	if value, source, ok, err := r.Lookup("", []string{"name", "user"}, []string{"NAME", "USER"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		v.Name = GenName(value)
+		r.Consume(source, []string{"NAME", "USER"})
+	}
+
+	// Debug
+	if value, source, ok, err := r.Lookup("d", []string{"debug"}, []string{"DEBUG"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x1, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.Debug = x1
+		r.Consume(source, []string{"DEBUG"})
+	}
+
+	// Workers
+	if value, source, ok, err := r.Lookup("", []string{"workers"}, []string{"WORKERS"}, "4", false, true); err != nil {
+		return err
+	} else if ok {
+		x2, err := env.ParseInt(value, strconv.IntSize)
+		if err != nil {
+			return err
+		}
+		v.Workers = int(x2)
+		r.Consume(source, []string{"WORKERS"})
+	}
+
+	// Small
+	if value, source, ok, err := r.Lookup("", []string{"small"}, []string{"SMALL"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x3, err := env.ParseInt(value, 8)
+		if err != nil {
+			return err
+		}
+		v.Small = int8(x3)
+		r.Consume(source, []string{"SMALL"})
+	}
+
+	// Big
+	if value, source, ok, err := r.Lookup("", []string{"big"}, []string{"BIG"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x4, err := env.ParseUint(value, 64)
+		if err != nil {
+			return err
+		}
+		v.Big = x4
+		r.Consume(source, []string{"BIG"})
+	}
+
+	// Ratio
+	if value, source, ok, err := r.Lookup("", []string{"ratio"}, []string{"RATIO"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x5, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return err
+		}
+		v.Ratio = float32(x5)
+		r.Consume(source, []string{"RATIO"})
+	}
+
+	// Scale
+	if value, source, ok, err := r.Lookup("", []string{"scale"}, []string{"SCALE"}, "1.5", false, true); err != nil {
+		return err
+	} else if ok {
+		x6, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.Scale = x6
+		r.Consume(source, []string{"SCALE"})
+	}
+
+	// Timeout
+	if value, source, ok, err := r.Lookup("", []string{"timeout"}, []string{"TIMEOUT"}, "30s", false, true); err != nil {
+		return err
+	} else if ok {
+		x7, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.Timeout = time.Duration(x7)
+		r.Consume(source, []string{"TIMEOUT"})
+	}
+
+	// Level
+	if value, source, ok, err := r.Lookup("", []string{"level"}, []string{"LEVEL"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		if err := (&v.Level).UnmarshalEnvironmentValue(value); err != nil {
+			return err
+		}
+		r.Consume(source, []string{"LEVEL"})
+	}
+
+	// LevelPtr
+	if value, source, ok, err := r.Lookup("", []string{"level-ptr"}, []string{"LEVEL_PTR"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x8 := new(GenLevel)
+		if err := x8.UnmarshalEnvironmentValue(value); err != nil {
+			return err
+		}
+		v.LevelPtr = x8
+		r.Consume(source, []string{"LEVEL_PTR"})
+	}
+
+	// Optional
+	if value, source, ok, err := r.Lookup("", []string{"optional"}, []string{"OPTIONAL"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x9 := new(string)
+		(*x9) = value
+		v.Optional = x9
+		r.Consume(source, []string{"OPTIONAL"})
+	}
+
+	// Count
+	if value, source, ok, err := r.Lookup("", []string{"count"}, []string{"COUNT"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x10 := new(int)
+		x11, err := env.ParseInt(value, strconv.IntSize)
+		if err != nil {
+			return err
+		}
+		(*x10) = int(x11)
+		v.Count = x10
+		r.Consume(source, []string{"COUNT"})
+	}
+
+	// Tags
+	if value, source, ok, err := r.Lookup("", []string{"tags"}, []string{"TAGS"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		if value == "" {
+			v.Tags = make([]string, 0)
+		} else {
+			x12 := strings.Split(value, ";")
+			x13 := make([]string, len(x12))
+			for x14, x15 := range x12 {
+				x13[x14] = x15
+			}
+			v.Tags = x13
+		}
+		r.Consume(source, []string{"TAGS"})
+	}
+
+	// Ports
+	if value, source, ok, err := r.Lookup("", []string{"ports"}, []string{"PORTS"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		if value == "" {
+			v.Ports = make([]int, 0)
+		} else {
+			x16 := strings.Split(value, "|")
+			x17 := make([]int, len(x16))
+			for x18, x19 := range x16 {
+				x20, err := env.ParseInt(x19, strconv.IntSize)
+				if err != nil {
+					return err
+				}
+				x17[x18] = int(x20)
+			}
+			v.Ports = x17
+		}
+		r.Consume(source, []string{"PORTS"})
+	}
+
+	// Names
+	if value, source, ok, err := r.Lookup("", []string{"names"}, []string{"NAMES"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		if value == "" {
+			v.Names = make([]GenName, 0)
+		} else {
+			x21 := strings.Split(value, "|")
+			x22 := make([]GenName, len(x21))
+			for x23, x24 := range x21 {
+				x22[x23] = GenName(x24)
+			}
+			v.Names = x22
+		}
+		r.Consume(source, []string{"NAMES"})
+	}
+
+	// Levels
+	if value, source, ok, err := r.Lookup("", []string{"levels"}, []string{"LEVELS"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		if value == "" {
+			v.Levels = make([]GenLevel, 0)
+		} else {
+			x25 := strings.Split(value, "|")
+			x26 := make([]GenLevel, len(x25))
+			for x27, x28 := range x25 {
+				if err := (&x26[x27]).UnmarshalEnvironmentValue(x28); err != nil {
+					return err
+				}
+			}
+			v.Levels = x26
+		}
+		r.Consume(source, []string{"LEVELS"})
+	}
+
+	// Limits
+	if value, source, ok, err := r.Lookup("", []string{"limits"}, []string{"LIMITS"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x29 := make(map[string]int)
+		if value != "" {
+			for _, x30 := range strings.Split(value, "|") {
+				x31 := strings.SplitN(x30, "=", 2)
+				if len(x31) != 2 {
+					return fmt.Errorf("map entry %q must have format key=value", x30)
+				}
+				var x32 string
+				x32 = x31[0]
+				var x33 int
+				x34, err := env.ParseInt(x31[1], strconv.IntSize)
+				if err != nil {
+					return err
+				}
+				x33 = int(x34)
+				x29[x32] = x33
+			}
+		}
+		v.Limits = x29
+		r.Consume(source, []string{"LIMITS"})
+	}
+
+	// Started
+	if value, source, ok, err := r.Lookup("", []string{"started"}, []string{"STARTED"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x35, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return err
+		}
+		v.Started = x35
+		r.Consume(source, []string{"STARTED"})
+	}
+
+	// Zone
+	if value, source, ok, err := r.Lookup("", []string{"zone"}, []string{"ZONE"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x36, err := time.LoadLocation(value)
+		if err != nil {
+			return err
+		}
+		v.Zone = x36
+		r.Consume(source, []string{"ZONE"})
+	}
+
+	// Upstream
+	if value, source, ok, err := r.Lookup("", []string{"upstream"}, []string{"UPSTREAM"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x37 := new(url.URL)
+		x38, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		(*x37) = *x38
+		v.Upstream = x37
+		r.Consume(source, []string{"UPSTREAM"})
+	}
+
+	// Allowed
+	if value, source, ok, err := r.Lookup("", []string{"allowed"}, []string{"ALLOWED"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		if value == "" {
+			v.Allowed = make([]netip.Prefix, 0)
+		} else {
+			x39 := strings.Split(value, "|")
+			x40 := make([]netip.Prefix, len(x39))
+			for x41, x42 := range x39 {
+				if err := (&x40[x41]).UnmarshalText([]byte(x42)); err != nil {
+					return err
+				}
+			}
+			v.Allowed = x40
+		}
+		r.Consume(source, []string{"ALLOWED"})
+	}
+
+	// Gateway
+	if value, source, ok, err := r.Lookup("", []string{"gateway"}, []string{"GATEWAY"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		if err := (&v.Gateway).UnmarshalText([]byte(value)); err != nil {
+			return err
+		}
+		r.Consume(source, []string{"GATEWAY"})
+	}
+
+	// Filter
+	if value, source, ok, err := r.Lookup("", []string{"filter"}, []string{"FILTER"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x43, err := regexp.Compile(value)
+		if err != nil {
+			return err
+		}
+		v.Filter = x43
+		r.Consume(source, []string{"FILTER"})
+	}
+
+	// Secret
+	if value, source, ok, err := r.Lookup("", []string{"secret"}, []string{"SECRET"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x44, err := env.DecodeBytes(value, "hex", 4)
+		if err != nil {
+			return err
+		}
+		v.Secret = x44
+		r.Consume(source, []string{"SECRET"})
+	}
+
+	// Payload
+	if value, source, ok, err := r.Lookup("", []string{"payload"}, []string{"PAYLOAD"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x45, err := env.DecodeBytes(value, "", 0)
+		if err != nil {
+			return err
+		}
+		v.Payload = x45
+		r.Consume(source, []string{"PAYLOAD"})
+	}
+
+	// Nonce
+	if value, source, ok, err := r.Lookup("", []string{"nonce"}, []string{"NONCE"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		x46, err := env.DecodeBytes(value, "base64", 4)
+		if err != nil {
+			return err
+		}
+		copy(v.Nonce[:], x46)
+		r.Consume(source, []string{"NONCE"})
+	}
+
+	// Weights
+	if value, source, ok, err := r.Lookup("", []string{"weights"}, []string{"WEIGHTS"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		var x47 []string
+		if value != "" {
+			x47 = strings.Split(value, ";")
+		}
+		if len(x47) != 3 {
+			return fmt.Errorf("expected 3 elements, got %d", len(x47))
+		}
+		var x48 [3]float64
+		for x49, x50 := range x47 {
+			x51, err := strconv.ParseFloat(x50, 64)
+			if err != nil {
+				return err
+			}
+			x48[x49] = x51
+		}
+		v.Weights = x48
+		r.Consume(source, []string{"WEIGHTS"})
+	}
+
+	// Mode
+	if value, source, ok, err := r.Lookup("", []string{"mode"}, []string{"MODE"}, "fast", false, true); err != nil {
+		return err
+	} else if ok {
+		x52, err := env.EnumValue[GenMode](value)
+		if err != nil {
+			return err
+		}
+		v.Mode = x52
+		r.Consume(source, []string{"MODE"})
+	}
+
+	// Modes
+	if value, source, ok, err := r.Lookup("", []string{"modes"}, []string{"MODES"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		if value == "" {
+			v.Modes = make([]GenMode, 0)
+		} else {
+			x53 := strings.Split(value, "|")
+			x54 := make([]GenMode, len(x53))
+			for x55, x56 := range x53 {
+				x57, err := env.EnumValue[GenMode](x56)
+				if err != nil {
+					return err
+				}
+				x54[x55] = x57
+			}
+			v.Modes = x54
+		}
+		r.Consume(source, []string{"MODES"})
+	}
+
+	// ModePair
+	if value, source, ok, err := r.Lookup("", []string{"mode-pair"}, []string{"MODE_PAIR"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		var x58 []string
+		if value != "" {
+			x58 = strings.Split(value, "|")
+		}
+		if len(x58) != 2 {
+			return fmt.Errorf("expected 2 elements, got %d", len(x58))
+		}
+		var x59 [2]GenMode
+		for x60, x61 := range x58 {
+			x62, err := env.EnumValue[GenMode](x61)
+			if err != nil {
+				return err
+			}
+			x59[x60] = x62
+		}
+		v.ModePair = x59
+		r.Consume(source, []string{"MODE_PAIR"})
+	}
+
+	// NamePair
+	if value, source, ok, err := r.Lookup("", []string{"name-pair"}, []string{"NAME_PAIR"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		var x63 []string
+		if value != "" {
+			x63 = strings.Split(value, "|")
+		}
+		if len(x63) != 2 {
+			return fmt.Errorf("expected 2 elements, got %d", len(x63))
+		}
+		var x64 [2]GenName
+		for x65, x66 := range x63 {
+			x64[x65] = GenName(x66)
+		}
+		v.NamePair = x64
+		r.Consume(source, []string{"NAME_PAIR"})
+	}
+
+	// Resolvers
+	if value, source, ok, err := r.Lookup("", []string{"resolvers"}, []string{"RESOLVERS"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		var x67 []string
+		if value != "" {
+			x67 = strings.Split(value, "|")
+		}
+		if len(x67) != 2 {
+			return fmt.Errorf("expected 2 elements, got %d", len(x67))
+		}
+		var x68 [2]netip.Addr
+		for x69, x70 := range x67 {
+			if err := (&x68[x69]).UnmarshalText([]byte(x70)); err != nil {
+				return err
+			}
+		}
+		v.Resolvers = x68
+		r.Consume(source, []string{"RESOLVERS"})
+	}
+
+	// Database.Host
+	if value, source, ok, err := r.Lookup("", []string{"db-host"}, []string{"DB_HOST"}, "", false, true); err != nil {
+		return err
+	} else if ok {
+		v.Database.Host = value
+		r.Consume(source, []string{"DB_HOST"})
+	}
+
+	// Database.Port
+	if value, source, ok, err := r.Lookup("", []string{"db-port"}, []string{"DB_PORT"}, "5432", false, true); err != nil {
+		return err
+	} else if ok {
+		x71, err := env.ParseUint(value, 16)
+		if err != nil {
+			return err
+		}
+		v.Database.Port = uint16(x71)
+		r.Consume(source, []string{"DB_PORT"})
+	}
+
+	// Cache.URL
+	if v.Cache != nil {
+		if value, source, ok, err := r.Lookup("", []string{"cache-url"}, []string{"CACHE_URL"}, "", false, true); err != nil {
+			return err
+		} else if ok {
+			v.Cache.URL = value
+			r.Consume(source, []string{"CACHE_URL"})
+		}
+	}
+
+	// Cache.TTL
+	if v.Cache != nil {
+		if value, source, ok, err := r.Lookup("", []string{"cache-ttl"}, []string{"CACHE_TTL"}, "1m", false, true); err != nil {
+			return err
+		} else if ok {
+			x72, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			v.Cache.TTL = time.Duration(x72)
+			r.Consume(source, []string{"CACHE_TTL"})
+		}
+	}
+
+	// Cache.Pool.Size
+	if v.Cache != nil && v.Cache.Pool != nil {
+		if value, source, ok, err := r.Lookup("", []string{"pool-size"}, []string{"POOL_SIZE"}, "4", false, true); err != nil {
+			return err
+		} else if ok {
+			x73, err := env.ParseInt(value, strconv.IntSize)
+			if err != nil {
+				return err
+			}
+			v.Cache.Pool.Size = int(x73)
+			r.Consume(source, []string{"POOL_SIZE"})
+		}
+	}
+	return nil
+}
+
+// MarshalEnv implements env.EnvMarshaler.
+func (v *GenStruct) MarshalEnv() (env.EnvSet, error) {
+	es := make(env.EnvSet)
+
+	// Home
+	{
+		var value string
+		value = v.Home
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["HOME"] = value
+	}
+
+	// Name
+	{
+		var value string
+		value = string(v.Name)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["NAME"] = value
+		es["USER"] = value
+	}
+
+	// Debug
+	{
+		var value string
+		value = strconv.FormatBool(v.Debug)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["DEBUG"] = value
+	}
+
+	// Workers
+	{
+		var value string
+		value = strconv.FormatInt(int64(v.Workers), 10)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["WORKERS"] = value
+	}
+
+	// Small
+	{
+		var value string
+		value = strconv.FormatInt(int64(v.Small), 10)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["SMALL"] = value
+	}
+
+	// Big
+	{
+		var value string
+		value = strconv.FormatUint(v.Big, 10)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["BIG"] = value
+	}
+
+	// Ratio
+	{
+		var value string
+		value = strconv.FormatFloat(float64(v.Ratio), 'g', -1, 32)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["RATIO"] = value
+	}
+
+	// Scale
+	{
+		var value string
+		value = strconv.FormatFloat(v.Scale, 'g', -1, 64)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["SCALE"] = value
+	}
+
+	// Timeout
+	{
+		var value string
+		value = time.Duration(v.Timeout).String()
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["TIMEOUT"] = value
+	}
+
+	// Level
+	{
+		var value string
+		x1, err := v.Level.MarshalEnvironmentValue()
+		if err != nil {
+			return nil, err
+		}
+		value = x1
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["LEVEL"] = value
+	}
+
+	// LevelPtr
+	if v.LevelPtr != nil {
+		var value string
+		if v.LevelPtr != nil {
+			x2, err := v.LevelPtr.MarshalEnvironmentValue()
+			if err != nil {
+				return nil, err
+			}
+			value = x2
+		}
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["LEVEL_PTR"] = value
+	}
+
+	// Optional
+	if v.Optional != nil {
+		var value string
+		if v.Optional != nil {
+			value = (*v.Optional)
+		}
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["OPTIONAL"] = value
+	}
+
+	// Count
+	if v.Count != nil {
+		var value string
+		if v.Count != nil {
+			value = strconv.FormatInt(int64((*v.Count)), 10)
+		}
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["COUNT"] = value
+	}
+
+	// Tags
+	if v.Tags != nil {
+		var value string
+		x3 := make([]string, len(v.Tags))
+		for x4 := range v.Tags {
+			x3[x4] = v.Tags[x4]
+		}
+		x5, err := env.JoinValues(x3, ";")
+		if err != nil {
+			return nil, err
+		}
+		value = x5
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["TAGS"] = value
+	}
+
+	// Ports
+	if v.Ports != nil {
+		var value string
+		x6 := make([]string, len(v.Ports))
+		for x7 := range v.Ports {
+			x6[x7] = strconv.FormatInt(int64(v.Ports[x7]), 10)
+		}
+		x8, err := env.JoinValues(x6, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x8
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["PORTS"] = value
+	}
+
+	// Names
+	if v.Names != nil {
+		var value string
+		x9 := make([]string, len(v.Names))
+		for x10 := range v.Names {
+			x9[x10] = string(v.Names[x10])
+		}
+		x11, err := env.JoinValues(x9, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x11
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["NAMES"] = value
+	}
+
+	// Levels
+	if v.Levels != nil {
+		var value string
+		x12 := make([]string, len(v.Levels))
+		for x13 := range v.Levels {
+			x14, err := v.Levels[x13].MarshalEnvironmentValue()
+			if err != nil {
+				return nil, err
+			}
+			x12[x13] = x14
+		}
+		x15, err := env.JoinValues(x12, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x15
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["LEVELS"] = value
+	}
+
+	// Limits
+	if v.Limits != nil {
+		var value string
+		x16 := make([]string, 0, len(v.Limits))
+		x17 := make([]string, 0, len(v.Limits))
+		for x18, x19 := range v.Limits {
+			var x20, x21 string
+			x20 = x18
+			x21 = strconv.FormatInt(int64(x19), 10)
+			x16 = append(x16, x20)
+			x17 = append(x17, x21)
+		}
+		x22, err := env.JoinEntries(x16, x17, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x22
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["LIMITS"] = value
+	}
+
+	// Started
+	{
+		var value string
+		value = v.Started.Format("2006-01-02")
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["STARTED"] = value
+	}
+
+	// Zone
+	if v.Zone != nil {
+		var value string
+		if v.Zone != nil {
+			value = v.Zone.String()
+		}
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["ZONE"] = value
+	}
+
+	// Upstream
+	if v.Upstream != nil {
+		var value string
+		if v.Upstream != nil {
+			x23 := (*v.Upstream)
+			value = x23.String()
+		}
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["UPSTREAM"] = value
+	}
+
+	// Allowed
+	if v.Allowed != nil {
+		var value string
+		x24 := make([]string, len(v.Allowed))
+		for x25 := range v.Allowed {
+			x26, err := v.Allowed[x25].MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			x24[x25] = string(x26)
+		}
+		x27, err := env.JoinValues(x24, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x27
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["ALLOWED"] = value
+	}
+
+	// Gateway
+	{
+		var value string
+		x28, err := v.Gateway.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		value = string(x28)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["GATEWAY"] = value
+	}
+
+	// Filter
+	if v.Filter != nil {
+		var value string
+		if v.Filter != nil {
+			value = v.Filter.String()
+		}
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["FILTER"] = value
+	}
+
+	// Secret
+	if v.Secret != nil {
+		var value string
+		value = env.EncodeBytes(v.Secret, "hex")
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["SECRET"] = value
+	}
+
+	// Payload
+	if v.Payload != nil {
+		var value string
+		value = env.EncodeBytes(v.Payload, "")
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["PAYLOAD"] = value
+	}
+
+	// Nonce
+	{
+		var value string
+		x29 := v.Nonce
+		value = env.EncodeBytes(x29[:], "base64")
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["NONCE"] = value
+	}
+
+	// Weights
+	{
+		var value string
+		x30 := make([]string, len(v.Weights))
+		for x31 := range v.Weights {
+			x30[x31] = strconv.FormatFloat(v.Weights[x31], 'g', -1, 64)
+		}
+		x32, err := env.JoinValues(x30, ";")
+		if err != nil {
+			return nil, err
+		}
+		value = x32
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["WEIGHTS"] = value
+	}
+
+	// Mode
+	{
+		var value string
+		if x33, ok := env.EnumName(v.Mode); ok {
+			value = x33
+		} else {
+			value = strconv.FormatInt(int64(v.Mode), 10)
+		}
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["MODE"] = value
+	}
+
+	// Modes
+	if v.Modes != nil {
+		var value string
+		x34 := make([]string, len(v.Modes))
+		for x35 := range v.Modes {
+			if x36, ok := env.EnumName(v.Modes[x35]); ok {
+				x34[x35] = x36
+			} else {
+				x34[x35] = strconv.FormatInt(int64(v.Modes[x35]), 10)
+			}
+		}
+		x37, err := env.JoinValues(x34, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x37
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["MODES"] = value
+	}
+
+	// ModePair
+	{
+		var value string
+		x38 := make([]string, len(v.ModePair))
+		for x39 := range v.ModePair {
+			if x40, ok := env.EnumName(v.ModePair[x39]); ok {
+				x38[x39] = x40
+			} else {
+				x38[x39] = strconv.FormatInt(int64(v.ModePair[x39]), 10)
+			}
+		}
+		x41, err := env.JoinValues(x38, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x41
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["MODE_PAIR"] = value
+	}
+
+	// NamePair
+	{
+		var value string
+		x42 := make([]string, len(v.NamePair))
+		for x43 := range v.NamePair {
+			x42[x43] = string(v.NamePair[x43])
+		}
+		x44, err := env.JoinValues(x42, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x44
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["NAME_PAIR"] = value
+	}
+
+	// Resolvers
+	{
+		var value string
+		x45 := make([]string, len(v.Resolvers))
+		for x46 := range v.Resolvers {
+			x47, err := v.Resolvers[x46].MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			x45[x46] = string(x47)
+		}
+		x48, err := env.JoinValues(x45, "|")
+		if err != nil {
+			return nil, err
+		}
+		value = x48
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["RESOLVERS"] = value
+	}
+
+	// Database.Host
+	{
+		var value string
+		value = v.Database.Host
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["DB_HOST"] = value
+	}
+
+	// Database.Port
+	{
+		var value string
+		value = strconv.FormatUint(uint64(v.Database.Port), 10)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["DB_PORT"] = value
+	}
+
+	// Cache.URL
+	if v.Cache != nil {
+		var value string
+		value = v.Cache.URL
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["CACHE_URL"] = value
+	}
+
+	// Cache.TTL
+	if v.Cache != nil {
+		var value string
+		value = time.Duration(v.Cache.TTL).String()
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["CACHE_TTL"] = value
+	}
+
+	// Cache.Pool.Size
+	if v.Cache != nil && v.Cache.Pool != nil {
+		var value string
+		value = strconv.FormatInt(int64(v.Cache.Pool.Size), 10)
+		value = strings.ReplaceAll(value, "$", "$$")
+		es["POOL_SIZE"] = value
+	}
+	return es, nil
+}
+
+// RegisterEnvFlags implements env.EnvFlagRegisterer.
+func (v *GenStruct) RegisterEnvFlags(flags *flag.FlagSet) error {
+	flags.String("home", "", "Environment: HOME. Required: true")
+	flags.String("name", "", "Environment: NAME, USER")
+	flags.String("user", "", "Environment: NAME, USER")
+	flags.String("d", "", "enable debug output. Environment: DEBUG")
+	flags.String("debug", "", "enable debug output. Environment: DEBUG")
+	flags.String("workers", "4", "Environment: WORKERS. Default: 4")
+	flags.String("small", "", "Environment: SMALL")
+	flags.String("big", "", "Environment: BIG")
+	flags.String("ratio", "", "Environment: RATIO")
+	flags.String("scale", "1.5", "Environment: SCALE. Default: 1.5")
+	flags.String("timeout", "30s", "Environment: TIMEOUT. Default: 30s")
+	flags.String("level", "", "Environment: LEVEL")
+	flags.String("level-ptr", "", "Environment: LEVEL_PTR")
+	flags.String("optional", "", "Environment: OPTIONAL")
+	flags.String("count", "", "Environment: COUNT")
+	flags.String("tags", "", "Environment: TAGS")
+	flags.String("ports", "", "Environment: PORTS")
+	flags.String("names", "", "Environment: NAMES")
+	flags.String("levels", "", "Environment: LEVELS")
+	flags.String("limits", "", "Environment: LIMITS")
+	flags.String("started", "", "Environment: STARTED")
+	flags.String("zone", "", "Environment: ZONE")
+	flags.String("upstream", "", "Environment: UPSTREAM")
+	flags.String("allowed", "", "Environment: ALLOWED")
+	flags.String("gateway", "", "Environment: GATEWAY")
+	flags.String("filter", "", "Environment: FILTER")
+	flags.String("secret", "", "Environment: SECRET")
+	flags.String("payload", "", "Environment: PAYLOAD")
+	flags.String("nonce", "", "Environment: NONCE")
+	flags.String("weights", "", "Environment: WEIGHTS")
+	flags.String("mode", "fast", "Environment: MODE. Choices: safe, fast, turbo. Default: fast")
+	flags.String("modes", "", "Environment: MODES")
+	flags.String("mode-pair", "", "Environment: MODE_PAIR")
+	flags.String("name-pair", "", "Environment: NAME_PAIR")
+	flags.String("resolvers", "", "Environment: RESOLVERS")
+	flags.String("db-host", "", "database host. Environment: DB_HOST")
+	flags.String("db-port", "5432", "Environment: DB_PORT. Default: 5432")
+	flags.String("cache-url", "", "Environment: CACHE_URL")
+	flags.String("cache-ttl", "1m", "Environment: CACHE_TTL. Default: 1m")
+	flags.String("pool-size", "4", "Environment: POOL_SIZE. Default: 4")
+	return nil
+}
@@ -0,0 +1,403 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	env "github.com/TubbyStubby/go-env-flags"
+)
+
+//go:generate go test -run TestGenerateCode -update
+
+// genGolden holds the code generated for GenStruct. The tests below run
+// against it, so it is compiled into the test binary.
+const genGolden = "codegen_gen_test.go"
+
+var update = flag.Bool("update", false, "update "+genGolden)
+
+type GenLevel int
+
+func (l *GenLevel) UnmarshalEnvironmentValue(data string) error {
+	switch data {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", data)
+	}
+	return nil
+}
+
+func (l GenLevel) MarshalEnvironmentValue() (string, error) {
+	if l == 2 {
+		return "high", nil
+	}
+	return "low", nil
+}
+
+type GenName string
+
+type GenMode int
+
+func init() {
+	env.RegisterEnum(map[string]GenMode{"safe": 0, "fast": 1, "turbo": 2})
+}
+
+type GenNested struct {
+	Host string `env:"DB_HOST,desc=database host"`
+	Port uint16 `env:"DB_PORT,default=5432"`
+}
+
+type GenPool struct {
+	Size int `env:"POOL_SIZE,default=4"`
+}
+
+type GenCache struct {
+	URL  string        `env:"CACHE_URL"`
+	TTL  time.Duration `env:"CACHE_TTL,default=1m"`
+	Pool *GenPool      `env:",alloc=always"`
+}
+
+type GenStruct struct {
+	Home      string         `env:"HOME,required=true"`
+	Name      GenName        `env:"NAME,USER"`
+	Debug     bool           `env:"DEBUG,flag=d,desc=enable debug output"`
+	Workers   int            `env:"WORKERS,default=4"`
+	Small     int8           `env:"SMALL"`
+	Big       uint64         `env:"BIG"`
+	Ratio     float32        `env:"RATIO"`
+	Scale     float64        `env:"SCALE,default=1.5"`
+	Timeout   time.Duration  `env:"TIMEOUT,default=30s"`
+	Level     GenLevel       `env:"LEVEL"`
+	LevelPtr  *GenLevel      `env:"LEVEL_PTR"`
+	Optional  *string        `env:"OPTIONAL"`
+	Count     *int           `env:"COUNT"`
+	Tags      []string       `env:"TAGS,separator=;"`
+	Ports     []int          `env:"PORTS"`
+	Names     []GenName      `env:"NAMES"`
+	Levels    []GenLevel     `env:"LEVELS"`
+	Limits    map[string]int `env:"LIMITS"`
+	Started   time.Time      `env:"STARTED,layout=DateOnly"`
+	Zone      *time.Location `env:"ZONE"`
+	Upstream  *url.URL       `env:"UPSTREAM"`
+	Allowed   []netip.Prefix `env:"ALLOWED"`
+	Gateway   netip.Addr     `env:"GATEWAY"`
+	Filter    *regexp.Regexp `env:"FILTER"`
+	Secret    []byte         `env:"SECRET,encoding=hex,len=4"`
+	Payload   []byte         `env:"PAYLOAD"`
+	Nonce     [4]byte        `env:"NONCE,encoding=base64"`
+	Weights   [3]float64     `env:"WEIGHTS,separator=;"`
+	Mode      GenMode        `env:"MODE,default=fast"`
+	Modes     []GenMode      `env:"MODES"`
+	ModePair  [2]GenMode     `env:"MODE_PAIR"`
+	NamePair  [2]GenName     `env:"NAME_PAIR"`
+	Resolvers [2]netip.Addr  `env:"RESOLVERS"`
+	Database  GenNested
+	Cache     *GenCache
+	Untouched string
+}
+
+// reflectGenStruct has the fields of GenStruct but not its generated
+// methods, so it goes through the reflective path.
+type reflectGenStruct GenStruct
+
+func TestGenerateCode(t *testing.T) {
+	var b bytes.Buffer
+	if err := env.GenerateCode(&b, "env_test", new(GenStruct)); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if *update {
+		if err := os.WriteFile(genGolden, b.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	golden, err := os.ReadFile(genGolden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), golden) {
+		t.Errorf("Generated code differs from %s, run go generate", genGolden)
+	}
+}
+
+func TestGenerateCodeUnsupported(t *testing.T) {
+	t.Parallel()
+	type Anonymous struct {
+		Values map[string]interface{} `env:"VALUES"`
+	}
+	type Dynamic struct {
+		Backend interface{}
+	}
+
+	var b bytes.Buffer
+	if err := env.GenerateCode(&b, "env_test", new(Anonymous)); !errors.Is(err, env.ErrUnsupportedGenerate) {
+		t.Errorf("Expected error 'ErrUnsupportedGenerate' but got '%s'", err)
+	}
+	if err := env.GenerateCode(&b, "env_test", new(Dynamic)); !errors.Is(err, env.ErrUnsupportedGenerate) {
+		t.Errorf("Expected error 'ErrUnsupportedGenerate' but got '%s'", err)
+	}
+	if err := env.GenerateCode(&b, "env_test", GenStruct{}); !errors.Is(err, env.ErrInvalidValue) {
+		t.Errorf("Expected error 'ErrInvalidValue' but got '%s'", err)
+	}
+}
+
+func TestGeneratedUnmarshal(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		args []string
+		es   env.EnvSet
+	}{
+		{
+			name: "empty",
+			es:   env.EnvSet{},
+		},
+		{
+			name: "env",
+			es: env.EnvSet{
+				"HOME":      "/home/test",
+				"USER":      "test",
+				"DEBUG":     "true",
+				"WORKERS":   "8",
+				"SMALL":     "-3",
+				"BIG":       "18446744073709551615",
+				"RATIO":     "0.25",
+				"TIMEOUT":   "1m30s",
+				"LEVEL":     "high",
+				"LEVEL_PTR": "low",
+				"OPTIONAL":  "",
+				"COUNT":     "7",
+				"TAGS":      "a;b;c",
+				"PORTS":     "80|443",
+				"NAMES":     "",
+				"LEVELS":    "low|high",
+				"LIMITS":    "cpu=2|mem=512",
+				"STARTED":   "2026-01-02",
+				"ZONE":      "UTC",
+				"UPSTREAM":  "https://example.com/api",
+				"ALLOWED":   "10.0.0.0/8|::1/128",
+				"GATEWAY":   "10.0.0.1",
+				"FILTER":    "^a+$",
+				"SECRET":    "DEADbeef",
+				"PAYLOAD":   "a|b",
+				"NONCE":     "AQIDBA==",
+				"WEIGHTS":   "0.5;0.25;1",
+				"MODE":      "turbo",
+				"MODES":     "safe|turbo",
+				"MODE_PAIR": "turbo|safe",
+				"NAME_PAIR": "a|b",
+				"RESOLVERS": "10.0.0.1|::1",
+				"DB_HOST":   "db",
+				"OTHER":     "kept",
+			},
+		},
+		{
+			name: "flags",
+			args: []string{"-home", "/root", "-d", "false", "-db-port", "6543", "-limits", ""},
+			es:   env.EnvSet{"HOME": "/home/test", "DEBUG": "true", "DB_PORT": "1"},
+		},
+		{
+			name: "cache",
+			args: []string{"-pool-size", "8"},
+			es:   env.EnvSet{"HOME": "/home/test", "CACHE_TTL": "5m"},
+		},
+		{
+			name: "invalid int",
+			es:   env.EnvSet{"HOME": "/home/test", "WORKERS": "many"},
+		},
+		{
+			name: "invalid level",
+			es:   env.EnvSet{"HOME": "/home/test", "LEVELS": "low|mid"},
+		},
+		{
+			name: "invalid map",
+			es:   env.EnvSet{"HOME": "/home/test", "LIMITS": "cpu"},
+		},
+		{
+			name: "invalid time",
+			es:   env.EnvSet{"HOME": "/home/test", "STARTED": "2026-01-02T00:00:00Z"},
+		},
+		{
+			name: "invalid prefix",
+			es:   env.EnvSet{"HOME": "/home/test", "ALLOWED": "10.0.0.0/8|10.0.0.1"},
+		},
+		{
+			name: "invalid secret",
+			es:   env.EnvSet{"HOME": "/home/test", "SECRET": "deadbeef00"},
+		},
+		{
+			name: "invalid weights",
+			es:   env.EnvSet{"HOME": "/home/test", "WEIGHTS": "0.5;0.25"},
+		},
+		{
+			name: "invalid nonce",
+			es:   env.EnvSet{"HOME": "/home/test", "NONCE": "AQID"},
+		},
+		{
+			name: "invalid mode",
+			es:   env.EnvSet{"HOME": "/home/test", "MODES": "safe|slow"},
+		},
+		{
+			name: "invalid resolvers",
+			es:   env.EnvSet{"HOME": "/home/test", "RESOLVERS": "10.0.0.1"},
+		},
+		{
+			name: "invalid regexp",
+			es:   env.EnvSet{"HOME": "/home/test", "FILTER": "a("},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				generated GenStruct
+				reflected reflectGenStruct
+			)
+			genES, genErr := unmarshalArgs(&generated, tt.args, tt.es)
+			refES, refErr := unmarshalArgs(&reflected, tt.args, tt.es)
+
+			if fmt.Sprint(genErr) != fmt.Sprint(refErr) {
+				t.Fatalf("Expected error '%v' but got '%v'", refErr, genErr)
+			}
+			if !reflect.DeepEqual(GenStruct(reflected), generated) {
+				t.Errorf("Expected struct to be '%+v' but got '%+v'", reflected, generated)
+			}
+			if !reflect.DeepEqual(refES, genES) {
+				t.Errorf("Expected remaining EnvSet to be '%v' but got '%v'", refES, genES)
+			}
+		})
+	}
+}
+
+// unmarshalArgs registers the flags of v, parses args and unmarshals a copy
+// of es into v, returning what is left of the copy.
+func unmarshalArgs(v interface{}, args []string, es env.EnvSet) (env.EnvSet, error) {
+	flags, err := env.RegisterFlags(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	remaining := make(env.EnvSet, len(es))
+	for k, v := range es {
+		remaining[k] = v
+	}
+	err = env.Unmarshal(flags, remaining, v)
+	return remaining, err
+}
+
+func TestGeneratedMarshal(t *testing.T) {
+	t.Parallel()
+	optional := "maybe"
+	level := GenLevel(2)
+	tests := []GenStruct{
+		{},
+		{
+			Home:      "/home/test",
+			Name:      "test",
+			Debug:     true,
+			Workers:   -1,
+			Small:     127,
+			Big:       1 << 63,
+			Ratio:     0.1,
+			Scale:     1e-9,
+			Timeout:   time.Hour,
+			Level:     2,
+			LevelPtr:  &level,
+			Optional:  &optional,
+			Tags:      []string{"a", "b"},
+			Ports:     []int{},
+			Names:     []GenName{"x", "y"},
+			Levels:    []GenLevel{1, 2},
+			Limits:    map[string]int{"mem": 512, "cpu": 2},
+			Started:   time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+			Zone:      time.UTC,
+			Upstream:  &url.URL{Scheme: "https", Host: "example.com", Path: "/api"},
+			Allowed:   []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			Gateway:   netip.MustParseAddr("::1"),
+			Filter:    regexp.MustCompile("^a+$"),
+			Secret:    []byte{0xde, 0xad, 0xbe, 0xef},
+			Payload:   []byte("a|b"),
+			Nonce:     [4]byte{1, 2, 3, 4},
+			Weights:   [3]float64{0.5, 0, 1e9},
+			Mode:      2,
+			Modes:     []GenMode{0, 7},
+			ModePair:  [2]GenMode{2, 0},
+			NamePair:  [2]GenName{"x", "y"},
+			Resolvers: [2]netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("::1")},
+			Database:  GenNested{Host: "db", Port: 5432},
+			Cache:     &GenCache{URL: "redis://cache:6379"},
+		},
+		{Tags: []string{"a", "b;c"}},
+		{Names: []GenName{""}},
+		{Limits: map[string]int{"k=x": 1}},
+	}
+
+	for i, tt := range tests {
+		generated, genErr := env.Marshal(&tt)
+		reflected := reflectGenStruct(tt)
+		expected, refErr := env.Marshal(&reflected)
+
+		if fmt.Sprint(genErr) != fmt.Sprint(refErr) {
+			t.Fatalf("%d: Expected error '%v' but got '%v'", i, refErr, genErr)
+		}
+		if i > 1 && refErr == nil {
+			t.Errorf("%d: Expected an error for a value that does not round trip", i)
+		}
+		if !reflect.DeepEqual(expected, generated) {
+			t.Errorf("%d: Expected EnvSet to be '%v' but got '%v'", i, expected, generated)
+		}
+	}
+}
+
+func TestGeneratedRegisterFlags(t *testing.T) {
+	t.Parallel()
+	generated, err := env.RegisterFlags(new(GenStruct))
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected, err := env.RegisterFlags(new(reflectGenStruct))
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if got, want := flagDefaults(generated), flagDefaults(expected); got != want {
+		t.Errorf("Expected flags\n%s\nbut got\n%s", want, got)
+	}
+}
+
+func flagDefaults(flags *flag.FlagSet) string {
+	var b strings.Builder
+	flags.SetOutput(&b)
+	flags.PrintDefaults()
+	return b.String()
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GenerateBashCompletion writes a bash completion script for program to w.
+// The script completes every flag that RegisterFlags would create for v and,
+// for fields with a known set of values, the values following the flag.
+func GenerateBashCompletion(w io.Writer, program string, v interface{}) error {
+	fields, err := collectFields(v)
+	if err != nil {
+		return err
+	}
+
+	fn := "_" + shellIdent(program) + "_completions"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n", program)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur prev\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    if [[ \"$prev\" == \"=\" && $COMP_CWORD -ge 2 ]]; then\n")
+	b.WriteString("        prev=\"${COMP_WORDS[COMP_CWORD-2]}\"\n")
+	b.WriteString("    elif [[ \"$cur\" == \"=\" ]]; then\n")
+	b.WriteString("        cur=\"\"\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, f := range fields {
+		choices := f.choices()
+		if len(choices) == 0 || len(f.Flags) == 0 {
+			continue
+		}
+		var patterns []string
+		for _, name := range f.Flags {
+			patterns = append(patterns, "-"+name, "--"+name)
+		}
+		fmt.Fprintf(&b, "        %s)\n", strings.Join(patterns, "|"))
+		fmt.Fprintf(&b, "            COMPREPLY=( $(compgen -W %s -- \"$cur\") )\n", shellQuote(strings.Join(choices, " ")))
+		b.WriteString("            return 0\n")
+		b.WriteString("            ;;\n")
+	}
+	b.WriteString("    esac\n")
+
+	var names []string
+	for _, f := range fields {
+		for _, name := range f.Flags {
+			names = append(names, "-"+name)
+		}
+	}
+	fmt.Fprintf(&b, "    COMPREPLY=( $(compgen -W %s -- \"$cur\") )\n", shellQuote(strings.Join(names, " ")))
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, program)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// GenerateZshCompletion writes a zsh completion script for program to w. The
+// script completes every flag that RegisterFlags would create for v along
+// with its description and, where known, the accepted values.
+func GenerateZshCompletion(w io.Writer, program string, v interface{}) error {
+	fields, err := collectFields(v)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n", program)
+	b.WriteString("_arguments")
+	for _, f := range fields {
+		description := zshEscape(completionDescription(f))
+		action := ""
+		if choices := f.choices(); len(choices) > 0 {
+			action = "(" + strings.Join(choices, " ") + ")"
+		}
+		for _, name := range f.Flags {
+			spec := fmt.Sprintf("-%s=[%s]:value:%s", name, description, action)
+			fmt.Fprintf(&b, " \\\n    %s", shellQuote(spec))
+		}
+	}
+	b.WriteString("\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// GenerateFishCompletion writes a fish completion script for program to w.
+// The script completes every flag that RegisterFlags would create for v along
+// with its description and, where known, the accepted values.
+func GenerateFishCompletion(w io.Writer, program string, v interface{}) error {
+	fields, err := collectFields(v)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n", program)
+	for _, f := range fields {
+		description := fishQuote(completionDescription(f))
+		choices := f.choices()
+		for _, name := range f.Flags {
+			if len(choices) > 0 {
+				fmt.Fprintf(&b, "complete -c %s -o %s -d %s -x -a %s\n", program, name, description, fishQuote(strings.Join(choices, " ")))
+			} else {
+				fmt.Fprintf(&b, "complete -c %s -o %s -d %s -r\n", program, name, description)
+			}
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// completionDescription is the flag help text collapsed onto a single line.
+func completionDescription(f field) string {
+	return strings.Join(strings.Fields(f.description()), " ")
+}
+
+// shellIdent maps s onto a valid shell function name fragment.
+func shellIdent(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// shellQuote single quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// zshEscape escapes the characters that are special inside an _arguments
+// option description.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// fishQuote single quotes s for fish, which only treats \ and ' as special
+// inside single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type CompletionStruct struct {
+	Port  int    `env:"PORT,desc=The port: to listen on"`
+	Debug bool   `env:"DEBUG,flag=verbose"`
+	Name  string `env:"APP_NAME,desc=It's the name"`
+}
+
+func TestGenerateBashCompletion(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateBashCompletion(&b, "my-app", &CompletionStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	script := b.String()
+
+	for _, want := range []string{
+		"_my_app_completions() {",
+		"complete -F _my_app_completions my-app",
+		"'-port -verbose -debug -app-name'",
+		"-verbose|--verbose|-debug|--debug)",
+		"compgen -W 'true false'",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected script to contain '%s' but got:\n%s", want, script)
+		}
+	}
+}
+
+func TestGenerateZshCompletion(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateZshCompletion(&b, "my-app", &CompletionStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	script := b.String()
+
+	for _, want := range []string{
+		"#compdef my-app\n",
+		`'-port=[The port\: to listen on. Environment\: PORT]:value:'`,
+		`'-verbose=[Environment\: DEBUG]:value:(true false)'`,
+		`'-app-name=[It'\''s the name. Environment\: APP_NAME]:value:'`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected script to contain '%s' but got:\n%s", want, script)
+		}
+	}
+}
+
+func TestGenerateFishCompletion(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateFishCompletion(&b, "my-app", &CompletionStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	script := b.String()
+
+	for _, want := range []string{
+		"complete -c my-app -o port -d 'The port: to listen on. Environment: PORT' -r\n",
+		"complete -c my-app -o debug -d 'Environment: DEBUG' -x -a 'true false'\n",
+		`complete -c my-app -o app-name -d 'It\'s the name. Environment: APP_NAME' -r`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected script to contain '%s' but got:\n%s", want, script)
+		}
+	}
+}
+
+func TestGenerateCompletionInvalid(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateBashCompletion(&b, "my-app", CompletionStruct{}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected error 'ErrInvalidValue' but got '%s'", err)
+	}
+}
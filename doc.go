@@ -0,0 +1,129 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// referenceColumns are the column headers of the generated reference.
+var referenceColumns = []string{"Field", "Env", "Flags", "Type", "Choices", "Default", "Required", "Separator", "Description"}
+
+// GenerateMarkdown writes a Markdown table describing every "env" tagged
+// field of the struct pointed to by v to w. Fields are listed in declaration
+// order, with nested structs flattened into dotted field paths.
+//
+// If v is nil or not a pointer to a struct, GenerateMarkdown returns an
+// ErrInvalidValue.
+func GenerateMarkdown(w io.Writer, v interface{}) error {
+	rows, err := referenceRows(v)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(referenceColumns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(referenceColumns)) + "\n")
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = markdownEscape(cell)
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// GenerateText writes a plain-text table describing every "env" tagged field
+// of the struct pointed to by v to w. It lists the same information as
+// GenerateMarkdown in aligned columns.
+//
+// If v is nil or not a pointer to a struct, GenerateText returns an
+// ErrInvalidValue.
+func GenerateText(w io.Writer, v interface{}) error {
+	rows, err := referenceRows(v)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(referenceColumns, "\t")))
+	for _, row := range rows {
+		for i, cell := range row {
+			// keep every row on a single line so the columns stay aligned
+			row[i] = strings.Join(strings.Fields(cell), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// referenceRows returns one row of cells per tagged field in v, in the order
+// of referenceColumns.
+func referenceRows(v interface{}) ([][]string, error) {
+	fields, err := collectFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(fields))
+	for _, f := range fields {
+		flags := make([]string, len(f.Flags))
+		for i, name := range f.Flags {
+			flags[i] = "-" + name
+		}
+
+		required := "no"
+		if f.Tag.Required {
+			required = "yes"
+		}
+
+		rows = append(rows, []string{
+			f.Path,
+			strings.Join(f.Tag.Keys, ", "),
+			strings.Join(flags, ", "),
+			f.Type.String(),
+			strings.Join(f.enumChoices(), ", "),
+			f.Tag.Default,
+			required,
+			f.separator(),
+			f.Tag.Desc,
+		})
+	}
+	return rows, nil
+}
+
+// separator returns the separator used to split the value of a slice or
+// array field, or an empty string if the field is neither or holds bytes.
+func (f field) separator() string {
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) || t.Elem() == byteType {
+		return ""
+	}
+	return f.Tag.separator()
+}
+
+// markdownEscape escapes s for use inside a Markdown table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
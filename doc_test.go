@@ -0,0 +1,100 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DocStruct struct {
+	Port int `env:"PORT,default=80,desc=Port to listen on"`
+
+	Database struct {
+		Hosts   []string      `env:"DB_HOSTS,DATABASE_HOSTS,required=true"`
+		Timeout time.Duration `env:"DB_TIMEOUT,flag=db-t"`
+	}
+
+	Untagged string
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateMarkdown(&b, &DocStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := `| Field | Env | Flags | Type | Choices | Default | Required | Separator | Description |
+| --- | --- | --- | --- | --- | --- | --- | --- | --- |
+| Port | PORT | -port | int |  | 80 | no |  | Port to listen on |
+| Database.Hosts | DB_HOSTS, DATABASE_HOSTS | -db-hosts, -database-hosts | []string |  |  | yes | \| |  |
+| Database.Timeout | DB_TIMEOUT | -db-t, -db-timeout | time.Duration |  |  | no |  |  |
+`
+	if b.String() != expected {
+		t.Errorf("Expected markdown to be\n%s\nbut got\n%s", expected, b.String())
+	}
+}
+
+func TestGenerateText(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateText(&b, &DocStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines but got %d:\n%s", len(lines), b.String())
+	}
+	if !strings.HasPrefix(lines[0], "FIELD ") {
+		t.Errorf("Expected header line but got '%s'", lines[0])
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "Port PORT -port int 80 no Port to listen on" {
+		t.Errorf("Expected row for Port but got '%s'", lines[1])
+	}
+}
+
+func TestFieldSeparator(t *testing.T) {
+	t.Parallel()
+	type SeparatorStruct struct {
+		Slice  []int    `env:"SLICE"`
+		Array  *[2]int  `env:"ARRAY,separator=;"`
+		Bytes  []byte   `env:"BYTES"`
+		Key    [32]byte `env:"KEY"`
+		Scalar string   `env:"SCALAR"`
+	}
+
+	fields, err := collectFields(&SeparatorStruct{})
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := []string{"|", ";", "", "", ""}
+	for i, f := range fields {
+		if got := f.separator(); got != expected[i] {
+			t.Errorf("%s: Expected separator '%s' but got '%s'", f.Path, expected[i], got)
+		}
+	}
+}
+
+func TestGenerateMarkdownInvalid(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateMarkdown(&b, DocStruct{}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected error 'ErrInvalidValue' but got '%s'", err)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GenerateDotenvExample writes a .env template for the struct pointed to by v
+// to w. Every tagged field yields a KEY=value line holding its default, or an
+// empty value when it has none, preceded by its description and a REQUIRED
+// marker as comments. Fields of nested structs are grouped under a comment
+// naming the struct.
+//
+// Only the first key of a field is emitted, other keys are listed as aliases.
+// A default holding quotes, backslashes, a # or surrounding spaces is single
+// quoted, so the output can be read back by FileSource. Once comment and
+// blank lines are dropped, the lines of the other defaults are also accepted
+// by EnvironToEnvSet.
+//
+// If v is nil or not a pointer to a struct, GenerateDotenvExample returns an
+// ErrInvalidValue.
+func GenerateDotenvExample(v interface{}, w io.Writer) error {
+	fields, err := collectFields(v)
+	if err != nil {
+		return err
+	}
+
+	var (
+		b     strings.Builder
+		group string
+	)
+	for _, f := range fields {
+		if len(f.Tag.Keys) == 0 {
+			continue
+		}
+
+		var fieldGroup string
+		if dot := strings.LastIndex(f.Path, "."); dot >= 0 {
+			fieldGroup = f.Path[:dot]
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if fieldGroup != group {
+			group = fieldGroup
+			if group != "" {
+				fmt.Fprintf(&b, "# [%s]\n", group)
+			}
+		}
+
+		for _, line := range strings.Split(f.Tag.Desc, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+		if len(f.Tag.Keys) > 1 {
+			fmt.Fprintf(&b, "# Aliases: %s\n", strings.Join(f.Tag.Keys[1:], ", "))
+		}
+		if f.Tag.Required {
+			b.WriteString("# REQUIRED\n")
+		}
+		fmt.Fprintf(&b, "%s=%s\n", f.Tag.Keys[0], dotenvQuote(f.Tag.Default))
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// dotenvQuote single quotes s when an env file parser would not read it back
+// as is.
+func dotenvQuote(s string) string {
+	if strings.ContainsAny(s, "'\"\\#\n\r") || strings.TrimSpace(s) != s {
+		return shellQuote(s)
+	}
+	return s
+}
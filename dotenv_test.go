@@ -0,0 +1,84 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDotenvExample(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateDotenvExample(&DocStruct{}, &b); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := `# Port to listen on
+PORT=80
+
+# [Database]
+# Aliases: DATABASE_HOSTS
+# REQUIRED
+DB_HOSTS=
+
+DB_TIMEOUT=
+`
+	if b.String() != expected {
+		t.Errorf("Expected template to be\n%s\nbut got\n%s", expected, b.String())
+	}
+}
+
+func TestGenerateDotenvExampleParses(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateDotenvExample(&DefaultValueStruct{}, &b); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	var environ []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		environ = append(environ, line)
+	}
+
+	es, err := EnvironToEnvSet(environ)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	var (
+		defaultValueStruct DefaultValueStruct
+		flags              = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	)
+	if err := Unmarshal(flags, es, &defaultValueStruct); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+	if defaultValueStruct.DefaultKeyValueString != "key=value" {
+		t.Errorf("Expected field value to be '%s' but got '%s'", "key=value", defaultValueStruct.DefaultKeyValueString)
+	}
+}
+
+func TestGenerateDotenvExampleInvalid(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateDotenvExample(DocStruct{}, &b); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected error 'ErrInvalidValue' but got '%s'", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Encodings of []byte and [N]byte fields, chosen with the "encoding" tag
+// option.
+const (
+	// EncodingRaw takes the bytes of the value as is, the default
+	EncodingRaw = "raw"
+	// EncodingBase64 is standard base64, padded or not
+	EncodingBase64 = "base64"
+	// EncodingBase64URL is URL safe base64, padded or not
+	EncodingBase64URL = "base64url"
+	// EncodingHex is hexadecimal, in either case
+	EncodingHex = "hex"
+)
+
+// byteType is the reflect.Type of the elements of []byte
+var byteType = reflect.TypeOf(byte(0))
+
+// isBytes reports whether t is a []byte, which set and format treat as a
+// single encoded value rather than a list.
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem() == byteType
+}
+
+// isByteArray reports whether t is a [N]byte, which set and format treat as
+// a single encoded value of N bytes.
+func isByteArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem() == byteType
+}
+
+// DecodeBytes decodes value with encoding, one of the Encoding constants or
+// "" for raw. If length is positive the decoded value must have exactly
+// length bytes.
+//
+// DecodeBytes is used by Unmarshal and the code generated by cmd/envgen.
+func DecodeBytes(value, encoding string, length int) ([]byte, error) {
+	var (
+		b   []byte
+		err error
+	)
+	switch encoding {
+	case "", EncodingRaw:
+		b = []byte(value)
+	case EncodingBase64:
+		b, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+	case EncodingBase64URL:
+		b, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	case EncodingHex:
+		b, err = hex.DecodeString(value)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", encoding, err)
+	}
+	if length > 0 && len(b) != length {
+		return nil, fmt.Errorf("decoded value is %d bytes long, expected %d", len(b), length)
+	}
+	return b, nil
+}
+
+// EncodeBytes is the inverse of DecodeBytes. Base64 is written padded and
+// hexadecimal in lower case.
+func EncodeBytes(b []byte, encoding string) string {
+	switch encoding {
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	case EncodingBase64URL:
+		return base64.URLEncoding.EncodeToString(b)
+	case EncodingHex:
+		return hex.EncodeToString(b)
+	default:
+		return string(b)
+	}
+}
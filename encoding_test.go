@@ -0,0 +1,142 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type HMACKey []byte
+
+type BytesStruct struct {
+	Raw     []byte  `env:"RAW"`
+	Key     HMACKey `env:"HMAC_KEY,encoding=base64,len=8"`
+	Token   []byte  `env:"TOKEN,encoding=base64url"`
+	Salt    *[]byte `env:"SALT,encoding=hex"`
+	Default []byte  `env:"DEFAULT,encoding=hex,default=cafe"`
+}
+
+func TestDecodeBytes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value, encoding string
+		expected        []byte
+	}{
+		{"a|b,c", "", []byte("a|b,c")},
+		{"a|b,c", EncodingRaw, []byte("a|b,c")},
+		{"+/8=", EncodingBase64, []byte{0xfb, 0xff}},
+		{"+/8", EncodingBase64, []byte{0xfb, 0xff}},
+		{"-_8=", EncodingBase64URL, []byte{0xfb, 0xff}},
+		{"-_8", EncodingBase64URL, []byte{0xfb, 0xff}},
+		{"DEADbeef", EncodingHex, []byte{0xde, 0xad, 0xbe, 0xef}},
+		{"", EncodingHex, []byte{}},
+	}
+	for _, tt := range tests {
+		got, err := DecodeBytes(tt.value, tt.encoding, 0)
+		if err != nil {
+			t.Errorf("%s %s: Expected no error but got '%s'", tt.encoding, tt.value, err)
+		} else if !bytes.Equal(got, tt.expected) {
+			t.Errorf("%s %s: Expected %x but got %x", tt.encoding, tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestDecodeBytesInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value, encoding string
+		length          int
+		reason          string
+	}{
+		{"-_8", EncodingBase64, 0, "decoding base64: illegal base64 data"},
+		{"+/8", EncodingBase64URL, 0, "decoding base64url: illegal base64 data"},
+		{"abc", EncodingHex, 0, "decoding hex: encoding/hex: odd length hex string"},
+		{"zz", EncodingHex, 0, "decoding hex: encoding/hex: invalid byte"},
+		{"abcd", EncodingHex, 32, "decoded value is 2 bytes long, expected 32"},
+		{"abc", EncodingRaw, 2, "decoded value is 3 bytes long, expected 2"},
+		{"abc", "base32", 0, `unknown encoding "base32"`},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeBytes(tt.value, tt.encoding, tt.length); err == nil || !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("%s %s: Expected error '%s' but got '%v'", tt.encoding, tt.value, tt.reason, err)
+		}
+	}
+}
+
+func TestUnmarshalBytes(t *testing.T) {
+	t.Parallel()
+	es := EnvSet{
+		"RAW":      "1|2|3",
+		"HMAC_KEY": "c2VjcmV0ISE=",
+		"TOKEN":    "_-8",
+		"SALT":     "00ff",
+	}
+	var bytesStruct BytesStruct
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), es, &bytesStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := BytesStruct{
+		Raw:     []byte("1|2|3"),
+		Key:     HMACKey("secret!!"),
+		Token:   []byte{0xff, 0xef},
+		Salt:    &[]byte{0x00, 0xff},
+		Default: []byte{0xca, 0xfe},
+	}
+	if !reflect.DeepEqual(bytesStruct, expected) {
+		t.Errorf("Expected '%+v' but got '%+v'", expected, bytesStruct)
+	}
+
+	err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), EnvSet{"HMAC_KEY": "c2hvcnQ="}, &bytesStruct)
+	if err == nil || !strings.Contains(err.Error(), "decoded value is 5 bytes long, expected 8") {
+		t.Errorf("Expected a length error but got '%v'", err)
+	}
+}
+
+func TestMarshalBytes(t *testing.T) {
+	t.Parallel()
+	bytesStruct := BytesStruct{
+		Raw:     []byte("a$b"),
+		Key:     HMACKey("secret!!"),
+		Token:   []byte{0xff, 0xef},
+		Salt:    &[]byte{0x00, 0xff},
+		Default: []byte{},
+	}
+	es, err := Marshal(&bytesStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := EnvSet{
+		"RAW":      "a$$b",
+		"HMAC_KEY": "c2VjcmV0ISE=",
+		"TOKEN":    "_-8=",
+		"SALT":     "00ff",
+		"DEFAULT":  "",
+	}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected '%v' but got '%v'", expected, es)
+	}
+
+	var roundTrip BytesStruct
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), es, &roundTrip); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if !reflect.DeepEqual(roundTrip, bytesStruct) {
+		t.Errorf("Expected '%+v' to round trip but got '%+v'", bytesStruct, roundTrip)
+	}
+}
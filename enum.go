@@ -0,0 +1,160 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// enums holds the registered enum types, keyed by their reflect.Type.
+var enums sync.Map
+
+// enum is the name table of a registered enum type.
+type enum struct {
+	// names lists the accepted names in display order
+	names []string
+	// values maps names to values of the enum type
+	values map[string]interface{}
+	// canonical maps values to the name they are formatted as
+	canonical map[interface{}]string
+}
+
+// RegisterEnum registers T as an enum type accepting the names of table.
+// Fields of type T then only take one of those names, in env values, flags
+// and defaults alike, and errors list the accepted names. Flag help, shell
+// completions, reference docs and JSON schemas show them as choices.
+//
+// Several names may share a value; Marshal writes such a value as the name
+// sorting first. Values without a name are formatted like their underlying
+// type.
+//
+// Registering T again replaces its table. Enum types are usually registered
+// from an init function, before any struct using them is unmarshalled.
+func RegisterEnum[T comparable](table map[string]T) {
+	e := &enum{
+		values:    make(map[string]interface{}, len(table)),
+		canonical: make(map[interface{}]string, len(table)),
+	}
+	for name, v := range table {
+		e.names = append(e.names, name)
+		e.values[name] = v
+	}
+	sortEnumNames(e.names, e.values)
+	for _, name := range e.names {
+		if _, ok := e.canonical[e.values[name]]; !ok {
+			e.canonical[e.values[name]] = name
+		}
+	}
+	enums.Store(reflect.TypeOf((*T)(nil)).Elem(), e)
+}
+
+// RegisterEnumValues registers T as an enum type like RegisterEnum, naming
+// each of values after its String method. Names are listed in the order of
+// values. RegisterEnumValues panics if two values have the same name.
+func RegisterEnumValues[T interface {
+	comparable
+	fmt.Stringer
+}](values ...T) {
+	e := &enum{
+		values:    make(map[string]interface{}, len(values)),
+		canonical: make(map[interface{}]string, len(values)),
+	}
+	for _, v := range values {
+		name := v.String()
+		if _, ok := e.values[name]; ok {
+			panic(fmt.Sprintf("env: enum %T has several values named %q", v, name))
+		}
+		e.names = append(e.names, name)
+		e.values[name] = v
+		e.canonical[v] = name
+	}
+	enums.Store(reflect.TypeOf((*T)(nil)).Elem(), e)
+}
+
+// EnumValue returns the value of T named name, or an error listing the
+// accepted names. T must have been registered.
+//
+// EnumValue is used by Unmarshal and the code generated by cmd/envgen.
+func EnumValue[T comparable](name string) (T, error) {
+	var zero T
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	e := enumOf(t)
+	if e == nil {
+		return zero, fmt.Errorf("%s is not a registered enum", t)
+	}
+	v, err := e.value(t, name)
+	if err != nil {
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// EnumName returns the name v is formatted as, or false if T is not a
+// registered enum or v has no name.
+//
+// EnumName is used by Marshal and the code generated by cmd/envgen.
+func EnumName[T comparable](v T) (string, bool) {
+	e := enumOf(reflect.TypeOf((*T)(nil)).Elem())
+	if e == nil {
+		return "", false
+	}
+	name, ok := e.canonical[v]
+	return name, ok
+}
+
+// enumOf returns the enum registered for t, or nil.
+func enumOf(t reflect.Type) *enum {
+	if e, ok := enums.Load(t); ok {
+		return e.(*enum)
+	}
+	return nil
+}
+
+// value returns the value named name of the enum type t.
+func (e *enum) value(t reflect.Type, name string) (interface{}, error) {
+	v, ok := e.values[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %q, must be one of: %s", t, name, strings.Join(e.names, ", "))
+	}
+	return v, nil
+}
+
+// sortEnumNames sorts names by their value when values are numbers or
+// strings, so levels list from lowest to highest, and by name otherwise.
+func sortEnumNames(names []string, values map[string]interface{}) {
+	less := func(a, b reflect.Value) (bool, bool) {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int(), a.Int() == b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint(), a.Uint() == b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float(), a.Float() == b.Float()
+		case reflect.String:
+			return a.String() < b.String(), a.String() == b.String()
+		}
+		return false, true
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if isLess, equal := less(reflect.ValueOf(values[names[i]]), reflect.ValueOf(values[names[j]])); !equal {
+			return isLess
+		}
+		return names[i] < names[j]
+	})
+}
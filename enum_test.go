@@ -0,0 +1,167 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"encoding/json"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type LogLevel int
+
+type Region string
+
+const (
+	RegionEU Region = "eu-west-1"
+	RegionUS Region = "us-east-1"
+)
+
+func (r Region) String() string {
+	return strings.SplitN(string(r), "-", 2)[0]
+}
+
+func init() {
+	RegisterEnum(map[string]LogLevel{"debug": -1, "info": 0, "warn": 1, "warning": 1, "error": 2})
+	RegisterEnumValues(RegionUS, RegionEU)
+}
+
+type EnumStruct struct {
+	Level   LogLevel   `env:"LOG_LEVEL,default=info,desc=Log verbosity"`
+	Regions []Region   `env:"REGIONS"`
+	Backup  *Region    `env:"BACKUP_REGION"`
+	Levels  []LogLevel `env:"LEVELS"`
+}
+
+func TestUnmarshalEnum(t *testing.T) {
+	t.Parallel()
+	var enumStruct EnumStruct
+	es := EnvSet{"REGIONS": "eu|us", "BACKUP_REGION": "us", "LEVELS": "warning|debug"}
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), es, &enumStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	backup := RegionUS
+	expected := EnumStruct{Level: 0, Regions: []Region{RegionEU, RegionUS}, Backup: &backup, Levels: []LogLevel{1, -1}}
+	if !reflect.DeepEqual(enumStruct, expected) {
+		t.Errorf("Expected '%+v' but got '%+v'", expected, enumStruct)
+	}
+
+	tests := map[string]string{
+		"LOG_LEVEL=verbose":       `invalid env.LogLevel "verbose", must be one of: debug, info, warn, warning, error`,
+		"LOG_LEVEL=1":             `invalid env.LogLevel "1"`,
+		"REGIONS=eu|ap":           `invalid env.Region "ap", must be one of: us, eu`,
+		"BACKUP_REGION=eu-west-1": `invalid env.Region "eu-west-1"`,
+	}
+	for assignment, reason := range tests {
+		key, value, _ := strings.Cut(assignment, "=")
+		err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), EnvSet{key: value}, &enumStruct)
+		if err == nil || !strings.Contains(err.Error(), reason) {
+			t.Errorf("%s: Expected error '%s' but got '%v'", assignment, reason, err)
+		}
+	}
+}
+
+func TestMarshalEnum(t *testing.T) {
+	t.Parallel()
+	enumStruct := EnumStruct{Level: 1, Regions: []Region{RegionUS, RegionEU}, Levels: []LogLevel{2, 5}}
+	es, err := Marshal(&enumStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	// warn sorts before its alias warning, 5 has no name
+	expected := EnvSet{"LOG_LEVEL": "warn", "REGIONS": "us|eu", "LEVELS": "error|5"}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected '%v' but got '%v'", expected, es)
+	}
+}
+
+func TestEnumValueName(t *testing.T) {
+	t.Parallel()
+	if v, err := EnumValue[LogLevel]("error"); err != nil || v != 2 {
+		t.Errorf("Expected 2 but got %d, %v", v, err)
+	}
+	if _, err := EnumValue[LogLevel]("fatal"); err == nil {
+		t.Errorf("Expected an error for an unknown name")
+	}
+	if _, err := EnumValue[string]("a"); err == nil || !strings.Contains(err.Error(), "string is not a registered enum") {
+		t.Errorf("Expected an error for an unregistered type but got '%v'", err)
+	}
+	if name, ok := EnumName(RegionEU); !ok || name != "eu" {
+		t.Errorf("Expected 'eu' but got '%s', %t", name, ok)
+	}
+	if name, ok := EnumName(LogLevel(9)); ok {
+		t.Errorf("Expected no name but got '%s'", name)
+	}
+}
+
+func TestRegisterEnumValuesDuplicate(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), `several values named "us"`) {
+			t.Errorf("Expected a panic for a duplicate name but got '%v'", r)
+		}
+	}()
+	type DuplicateRegion struct{ Region }
+	RegisterEnumValues(DuplicateRegion{RegionUS}, DuplicateRegion{"us-west-2"})
+}
+
+func TestEnumChoices(t *testing.T) {
+	t.Parallel()
+	flags, err := RegisterFlags(&EnumStruct{})
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	usage := flags.Lookup("log-level").Usage
+	if want := "Log verbosity. Environment: LOG_LEVEL. Choices: debug, info, warn, warning, error. Default: info"; usage != want {
+		t.Errorf("Expected usage '%s' but got '%s'", want, usage)
+	}
+
+	var b strings.Builder
+	if err := GenerateBashCompletion(&b, "app", &EnumStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if want := "compgen -W 'us eu'"; !strings.Contains(b.String(), want) {
+		t.Errorf("Expected completion to contain '%s' but got:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	if err := GenerateMarkdown(&b, &EnumStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if want := "| Level | LOG_LEVEL | -log-level | env.LogLevel | debug, info, warn, warning, error | info |"; !strings.Contains(b.String(), want) {
+		t.Errorf("Expected markdown to contain '%s' but got:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	if err := GenerateJSONSchema(&b, &EnumStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Type    string
+			Enum    []string
+			Default interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(b.String()), &schema); err != nil {
+		t.Fatal(err)
+	}
+	level := schema.Properties["LOG_LEVEL"]
+	if level.Type != "string" || !reflect.DeepEqual(level.Enum, []string{"debug", "info", "warn", "warning", "error"}) || level.Default != "info" {
+		t.Errorf("Unexpected schema of LOG_LEVEL '%+v'", level)
+	}
+}
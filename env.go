@@ -35,7 +35,7 @@ const (
 	// field is required
 	tagKeyRequired = "required"
 	// tagKeySeparator is the key used in the struct field tag to specify a
-	// separator for slice fields
+	// separator for slice and map fields
 	tagKeySeparator = "separator"
 	// tagKeyFlag is the key used in the struct field tag to specify a different
 	// name for the env flag
@@ -43,6 +43,24 @@ const (
 	// tagKeyDesc is the key used in the struct field tag to specify a description
 	// note: this only comes with flag help
 	tagKeyDesc = "desc"
+	// tagKeyReload is the key used in the struct field tag to keep the startup
+	// value of a field when a Watcher reloads the configuration
+	tagKeyReload = "reload"
+	// tagKeyExpand is the key used in the struct field tag to turn off ${VAR}
+	// expansion of the field value
+	tagKeyExpand = "expand"
+	// tagKeyLayout is the key used in the struct field tag to specify the
+	// time.Parse layout of time.Time fields
+	tagKeyLayout = "layout"
+	// tagKeyEncoding is the key used in the struct field tag to specify the
+	// encoding of []byte fields
+	tagKeyEncoding = "encoding"
+	// tagKeyLen is the key used in the struct field tag to specify the exact
+	// decoded length of []byte fields
+	tagKeyLen = "len"
+	// tagKeyAlloc is the key used in the struct field tag to specify when
+	// pointer to struct fields are allocated
+	tagKeyAlloc = "alloc"
 )
 
 var (
@@ -58,6 +76,9 @@ var (
 
 	// unmarshalType is the reflect.Type element of the Unmarshaler interface
 	unmarshalType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+	// marshalType is the reflect.Type element of the Marshaler interface
+	marshalType = reflect.TypeOf((*Marshaler)(nil)).Elem()
 )
 
 // ErrMissingRequiredValue returned when a field with required=true contains no value or default
@@ -71,10 +92,30 @@ func (e ErrMissingRequiredValue) Error() string {
 	return fmt.Sprintf("value for this field is required [%s]", e.Value)
 }
 
+// ErrInvalidTag returned when the "env" field tag of a field is malformed.
+type ErrInvalidTag struct {
+	// Field is the dotted path of the field in the config struct
+	Field string
+	// Tag is the "env" field tag as written
+	Tag string
+	// Err describes what is wrong with the tag
+	Err error
+}
+
+func (e ErrInvalidTag) Error() string {
+	return fmt.Sprintf("invalid env tag %q of field %s: %s", e.Tag, e.Field, e.Err)
+}
+
+func (e ErrInvalidTag) Unwrap() error {
+	return e.Err
+}
+
 // Unmarshal parses an EnvSet and stores the result in the value pointed to by
-// v. Fields that are matched in v will be deleted from EnvSet, resulting in
-// an EnvSet with the remaining environment variables. If v is nil or not a
-// pointer to a struct, Unmarshal returns an ErrInvalidValue.
+// v. For every field set from the EnvSet, the key that supplied the value is
+// deleted from EnvSet, resulting in an EnvSet with the remaining environment
+// variables. The ConsumeAllKeys and PreserveEnvSet options change what is
+// deleted. If v is nil or not a pointer to a struct, Unmarshal returns an
+// ErrInvalidValue.
 //
 // Fields tagged with "env" will have the unmarshalled EnvSet of the matching
 // key from EnvSet. If the tagged field is not exported, Unmarshal returns
@@ -82,7 +123,25 @@ func (e ErrMissingRequiredValue) Error() string {
 //
 // If the field has a type that is unsupported, Unmarshal returns
 // ErrUnsupportedType.
-func Unmarshal(flags *flag.FlagSet, es EnvSet, v interface{}) error {
+//
+// Env values and defaults may reference other variables of the EnvSet as
+// ${VAR}, ${VAR:-fallback} when VAR is unset or empty, or ${VAR:?message}
+// to fail with message instead; $$ stands for a literal $. Referenced values
+// are expanded in turn and loops are reported as ErrExpansionCycle. Fields
+// tagged with "expand=false" are taken literally, as are flag values.
+//
+// Nested structs are traversed recursively. A nil pointer to a struct is
+// allocated when a flag or env key of a field below it is set, or always
+// when tagged with "alloc=always", as in `env:",alloc=always"`; the fields
+// below a pointer left nil get neither defaults nor ErrMissingRequiredValue.
+// Untagged interface fields holding a non-nil pointer to a struct are
+// traversed as well, but never allocated.
+//
+// Options such as Strict change how the EnvSet is matched against v.
+//
+// If v implements EnvUnmarshaler, as the code generated by cmd/envgen does,
+// its UnmarshalEnv method is used instead of reflection.
+func Unmarshal(flags *flag.FlagSet, es EnvSet, v interface{}, opts ...Option) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return ErrInvalidValue
@@ -93,84 +152,91 @@ func Unmarshal(flags *flag.FlagSet, es EnvSet, v interface{}) error {
 		return ErrInvalidValue
 	}
 
-	t := rv.Type()
-	for i := range rv.NumField() {
-		valueField := rv.Field(i)
-		if valueField.Kind() == reflect.Struct {
-			if !valueField.Addr().CanInterface() {
-				continue
-			}
-			if err := Unmarshal(flags, es, valueField.Addr().Interface()); err != nil {
-				return err
-			}
-		}
+	o := newOptions(opts)
+	var err error
+	if u, ok := v.(EnvUnmarshaler); ok && o.consume == consumeSource && !o.allErrors && !o.foldCase {
+		err = u.UnmarshalEnv(flags, es)
+	} else {
+		err = unmarshalStruct(flags, es, rv, o)
+	}
+	if err != nil && !o.allErrors {
+		return err
+	}
 
-		typeField := t.Field(i)
-		tag := typeField.Tag.Get("env")
-		if tag == "" {
-			continue
-		}
+	if len(o.strictPrefixes) > 0 {
+		err = errors.Join(err, checkUnknownKeys(es, rv, o.strictPrefixes, o.foldCase))
+	}
+	return err
+}
 
-		if !valueField.CanSet() {
-			return ErrUnexportedField
-		}
+func unmarshalStruct(flags *flag.FlagSet, es EnvSet, rv reflect.Value, o *options) error {
+	r := newResolver(flags, es, o.consume)
+	if o.foldCase {
+		r.foldCase(o.ambiguousKey)
+	}
+	return unmarshalValue(r, rv, o)
+}
 
-		envTag := parseTag(tag)
+// unmarshalValue sets the fields of the struct rv from r, then those of the
+// structs its interface fields point to.
+func unmarshalValue(r *Resolver, rv reflect.Value, o *options) error {
+	var errs []error
+	p, err := planOf(rv.Type())
+	if err != nil {
+		return err
+	}
 
-		var envValue string
-		var ok bool
+	p.allocPtrs(rv, r)
+	for _, field := range p.fields {
+		if !field.Exported {
+			return ErrUnexportedField
+		}
+		// fields below a nil pointer are neither defaulted nor required
+		fieldValue, err := rv.FieldByIndexErr(field.Index)
+		if err != nil {
+			continue
+		}
 
-		// check if any flags are set, either the flag tag or the key flags
-		flagName := envTag.Flag
-		if flagName != "" {
-			ok = isFlagSet(flags, flagName)
-			if ok {
-				f := flags.Lookup(flagName)
-				envValue = f.Value.String()
+		envTag := field.Tag
+		envValue, sourceKey, ok, err := r.Lookup(envTag.Flag, field.KeyFlags, envTag.Keys, envTag.Default, envTag.Required, !envTag.NoExpand)
+		if err != nil {
+			if !o.allErrors {
+				return err
 			}
+			errs = append(errs, err)
+			continue
 		}
 		if !ok {
-			for _, envKey := range envTag.Keys {
-				flagName = toFlagName(envKey)
-				ok = isFlagSet(flags, flagName)
-				if ok {
-					f := flags.Lookup(flagName)
-					envValue = f.Value.String()
-					break
-				}
-			}
+			continue
 		}
 
-		// if flag not set then check the env vars
-		if !ok {
-			for _, envKey := range envTag.Keys {
-				envValue, ok = es[envKey]
-				if ok {
-					break
-				}
+		if err := field.set(fieldValue, envValue); err != nil {
+			if !o.allErrors {
+				return err
 			}
-		}
-
-		if !ok {
-			if envTag.Default != "" {
-				envValue = envTag.Default
-			} else if envTag.Required {
-				return &ErrMissingRequiredValue{Value: envTag.Keys[0]}
-			} else {
-				continue
+			// without the key, several parse errors cannot be told apart
+			if sourceKey == "" {
+				sourceKey = envTag.Keys[0]
 			}
+			errs = append(errs, fmt.Errorf("invalid value for [%s]: %w", sourceKey, err))
+			continue
 		}
+		r.Consume(sourceKey, envTag.Keys)
+	}
 
-		if err := set(typeField.Type, valueField, envValue, envTag.Separator); err != nil {
-			return err
+	for _, sv := range p.ifaceStructs(rv) {
+		if err := unmarshalValue(r, sv, o); err != nil {
+			if !o.allErrors {
+				return err
+			}
+			errs = append(errs, err)
 		}
-		delete(es, tag)
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-func set(t reflect.Type, f reflect.Value, value, sliceSeparator string) error {
+func set(t reflect.Type, f reflect.Value, value string, envTag tag) error {
 	// See if the type implements Unmarshaler and use that first,
 	// otherwise, fallback to the previous logic
 	var isUnmarshaler bool
@@ -202,10 +268,39 @@ func set(t reflect.Type, f reflect.Value, value, sliceSeparator string) error {
 		}
 	}
 
+	if e := enumOf(t); e != nil {
+		v, err := e.value(t, value)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(v))
+		return nil
+	}
+	if isStdType(t) {
+		return setStdType(t, f, value, envTag)
+	}
+	if isBytes(t) {
+		b, err := DecodeBytes(value, envTag.Encoding, envTag.Len)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(b).Convert(t))
+		return nil
+	}
+	if isByteArray(t) {
+		// the decoded value must fill the array exactly
+		b, err := DecodeBytes(value, envTag.Encoding, t.Len())
+		if err != nil {
+			return err
+		}
+		reflect.Copy(f, reflect.ValueOf(b))
+		return nil
+	}
+
 	switch t.Kind() {
 	case reflect.Ptr:
 		ptr := reflect.New(t.Elem())
-		if err := set(t.Elem(), ptr.Elem(), value, sliceSeparator); err != nil {
+		if err := set(t.Elem(), ptr.Elem(), value, envTag); err != nil {
 			return err
 		}
 		f.Set(ptr)
@@ -240,35 +335,73 @@ func set(t reflect.Type, f reflect.Value, value, sliceSeparator string) error {
 			break
 		}
 
-		v, err := strconv.Atoi(value)
+		v, err := ParseInt(value, t.Bits())
 		if err != nil {
 			return err
 		}
-		f.SetInt(int64(v))
+		f.SetInt(v)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		v, err := strconv.ParseUint(value, 10, 64)
+		v, err := ParseUint(value, t.Bits())
 		if err != nil {
 			return err
 		}
 		f.SetUint(v)
 	case reflect.Slice:
-		if sliceSeparator == "" {
-			sliceSeparator = "|"
+		sliceSeparator := envTag.separator()
+		if value == "" {
+			f.Set(reflect.MakeSlice(t, 0, 0))
+			break
 		}
 		values := strings.Split(value, sliceSeparator)
-		switch t.Elem().Kind() {
-		case reflect.String:
+		switch t.Elem() {
+		case reflect.TypeOf(""):
 			// already []string, just set directly
 			f.Set(reflect.ValueOf(values))
 		default:
 			dest := reflect.MakeSlice(reflect.SliceOf(t.Elem()), len(values), len(values))
 			for i, v := range values {
-				if err := set(t.Elem(), dest.Index(i), v, sliceSeparator); err != nil {
+				if err := set(t.Elem(), dest.Index(i), v, envTag); err != nil {
 					return err
 				}
 			}
 			f.Set(dest)
 		}
+	case reflect.Array:
+		var values []string
+		if value != "" {
+			values = strings.Split(value, envTag.separator())
+		}
+		if len(values) != t.Len() {
+			return fmt.Errorf("expected %d elements, got %d", t.Len(), len(values))
+		}
+		dest := reflect.New(t).Elem()
+		for i, v := range values {
+			if err := set(t.Elem(), dest.Index(i), v, envTag); err != nil {
+				return err
+			}
+		}
+		f.Set(dest)
+	case reflect.Map:
+		sliceSeparator := envTag.separator()
+		dest := reflect.MakeMap(t)
+		if value != "" {
+			for _, entry := range strings.Split(value, sliceSeparator) {
+				kv := strings.SplitN(entry, "=", 2)
+				if len(kv) != 2 {
+					return fmt.Errorf("map entry %q must have format key=value", entry)
+				}
+				k := reflect.New(t.Key()).Elem()
+				if err := set(t.Key(), k, kv[0], envTag); err != nil {
+					return err
+				}
+				v := reflect.New(t.Elem()).Elem()
+				if err := set(t.Elem(), v, kv[1], envTag); err != nil {
+					return err
+				}
+				dest.SetMapIndex(k, v)
+			}
+		}
+		f.Set(dest)
 	default:
 		return ErrUnsupportedType
 	}
@@ -276,6 +409,93 @@ func set(t reflect.Type, f reflect.Value, value, sliceSeparator string) error {
 	return nil
 }
 
+// format is the inverse of set: it returns the string that set parses back
+// into v. Slice elements and map entries are joined with the separator of
+// envTag.
+func format(v reflect.Value, envTag tag) (string, error) {
+	// See if the type implements Marshaler and use that first, mirroring the
+	// Unmarshaler lookup in set
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return "", nil
+	}
+	if v.CanInterface() {
+		if m, ok := v.Interface().(Marshaler); ok {
+			return m.MarshalEnvironmentValue()
+		}
+	}
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if m, ok := v.Addr().Interface().(Marshaler); ok {
+			return m.MarshalEnvironmentValue()
+		}
+	}
+
+	t := v.Type()
+	if e := enumOf(t); e != nil && v.CanInterface() {
+		// values without a name fall back to their underlying type
+		if name, ok := e.canonical[v.Interface()]; ok {
+			return name, nil
+		}
+	}
+	if isStdType(t) {
+		return formatStdType(v, envTag)
+	}
+	if isBytes(t) {
+		return EncodeBytes(v.Bytes(), envTag.Encoding), nil
+	}
+	if isByteArray(t) {
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return EncodeBytes(b, envTag.Encoding), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return format(v.Elem(), envTag)
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, t.Bits()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			return time.Duration(v.Int()).String(), nil
+		}
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Slice, reflect.Array:
+		values := make([]string, v.Len())
+		for i := range values {
+			s, err := format(v.Index(i), envTag)
+			if err != nil {
+				return "", err
+			}
+			values[i] = s
+		}
+		return JoinValues(values, envTag.separator())
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		values := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k, err := format(iter.Key(), envTag)
+			if err != nil {
+				return "", err
+			}
+			e, err := format(iter.Value(), envTag)
+			if err != nil {
+				return "", err
+			}
+			keys = append(keys, k)
+			values = append(values, e)
+		}
+		return JoinEntries(keys, values, envTag.separator())
+	default:
+		return "", ErrUnsupportedType
+	}
+}
+
 // UnmarshalFromEnviron parses an EnvSet from os.Environ and stores the result
 // in the value pointed to by v. Fields that weren't matched in v are returned
 // in an EnvSet with the remaining environment variables. If v is nil or not a
@@ -287,12 +507,20 @@ func set(t reflect.Type, f reflect.Value, value, sliceSeparator string) error {
 //
 // If the field has a type that is unsupported, UnmarshalFromEnviron returns
 // ErrUnsupportedType.
-func UnmarshalFromEnviron(v interface{}) (*flag.FlagSet, EnvSet, error) {
+//
+// Unknown command line flags are ignored unless the Strict option is given.
+func UnmarshalFromEnviron(v interface{}, opts ...Option) (*flag.FlagSet, EnvSet, error) {
 	flags, err := RegisterFlags(v)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if o := newOptions(opts); o.strict {
+		if err := checkUnknownFlags(flags, os.Args[1:]); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	filteredArgs := filterUndefinedAndDups(flags, os.Args[1:])
 	err = flags.Parse(filteredArgs)
 	if err != nil {
@@ -304,16 +532,25 @@ func UnmarshalFromEnviron(v interface{}) (*flag.FlagSet, EnvSet, error) {
 		return nil, nil, err
 	}
 
-	return flags, es, Unmarshal(flags, es, v)
+	return flags, es, Unmarshal(flags, es, v, opts...)
 }
 
 // Marshal returns an EnvSet of v. If v is nil or not a pointer, Marshal returns
 // an ErrInvalidValue.
 //
-// Marshal uses fmt.Sprintf to transform encountered values to its default
-// string format. Values without the "env" field tag are ignored.
+// Marshal formats every value so that Unmarshal parses it back into the same
+// value: slices and maps are joined with the separator of the field tag and
+// types implementing Marshaler format themselves. Slices and maps whose
+// elements would be split differently are an error, see JoinValues and
+// JoinEntries. Nil pointers, slices and maps are omitted. A $ is written as
+// $$, which Unmarshal expands back, unless the field is tagged with
+// "expand=false". Values without the "env" field tag are ignored. If a
+// tagged field has a type that is unsupported, Marshal returns
+// ErrUnsupportedType.
 //
-// Nested structs are traversed recursively.
+// Nested structs are traversed recursively, skipping nil pointers to
+// structs, and so are the structs interface fields point to. If v
+// implements EnvMarshaler, its MarshalEnv method is used instead.
 func Marshal(v interface{}) (EnvSet, error) {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
@@ -325,69 +562,64 @@ func Marshal(v interface{}) (EnvSet, error) {
 		return nil, ErrInvalidValue
 	}
 
+	if m, ok := v.(EnvMarshaler); ok {
+		return m.MarshalEnv()
+	}
+
 	es := make(EnvSet)
-	t := rv.Type()
-	for i := range rv.NumField() {
-		valueField := rv.Field(i)
-		if valueField.Kind() == reflect.Struct {
-			if !valueField.Addr().CanInterface() {
-				continue
-			}
+	if err := marshalValue(es, rv); err != nil {
+		return nil, err
+	}
+	return es, nil
+}
 
-			nes, err := Marshal(valueField.Addr().Interface())
-			if err != nil {
-				return nil, err
-			}
+// marshalValue adds the fields of the struct rv to es, then those of the
+// structs its interface fields point to.
+func marshalValue(es EnvSet, rv reflect.Value) error {
+	p, err := planOf(rv.Type())
+	if err != nil {
+		return err
+	}
 
-			for k, v := range nes {
-				es[k] = v
-			}
+	for _, field := range p.fields {
+		if !field.Exported {
+			return ErrUnexportedField
 		}
 
-		typeField := t.Field(i)
-		tag := typeField.Tag.Get("env")
-		if tag == "" {
+		// fields below a nil pointer are left unset
+		valueField, err := rv.FieldByIndexErr(field.Index)
+		if err != nil {
 			continue
 		}
-
-		envKeys := strings.Split(tag, ",")
-
-		var el interface{}
-		if typeField.Type.Kind() == reflect.Ptr {
+		switch valueField.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			// nil values are left unset, just like set leaves them when the
+			// environment variable is missing
 			if valueField.IsNil() {
 				continue
 			}
-			el = valueField.Elem().Interface()
-		} else {
-			el = valueField.Interface()
 		}
 
-		var (
-			err      error
-			envValue string
-		)
-		if m, ok := el.(Marshaler); ok {
-			envValue, err = m.MarshalEnvironmentValue()
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			envValue = fmt.Sprintf("%v", el)
+		envValue, err := format(valueField, field.Tag)
+		if err != nil {
+			return err
+		}
+		if !field.Tag.NoExpand {
+			// Unmarshal expands the value again, so a literal $ must survive
+			envValue = escapeExpansion(envValue)
 		}
 
-		for _, envKey := range envKeys {
-			// Skip keys with '=', as they represent tag options and not environment variable names.
-			if strings.Contains(envKey, "=") {
-				switch strings.ToLower(strings.SplitN(envKey, "=", 2)[0]) {
-				case "separator", "required", "default", "flag", "desc":
-					continue
-				}
-			}
+		for _, envKey := range field.Tag.Keys {
 			es[envKey] = envValue
 		}
 	}
 
-	return es, nil
+	for _, sv := range p.ifaceStructs(rv) {
+		if err := marshalValue(es, sv); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // tag is a struct used to store the parsed "env" field tag when unmarshalling.
@@ -398,40 +630,175 @@ type tag struct {
 	Default string
 	// Required is used to specify that the field is required
 	Required bool
-	// Separator is used to split the value of a slice field
+	// Separator is used to split the value of a slice or map field
 	Separator string
 	// Flag is used to provide alternative name for the env flag
 	Flag string
 	// Desc is used to provide a description for the field
 	Desc string
+	// NoReload is used to keep the startup value of the field on reload
+	NoReload bool
+	// NoExpand is used to take the field value literally, without expanding
+	// ${VAR} references
+	NoExpand bool
+	// Layout is used to parse and format time.Time fields, RFC 3339 when
+	// empty
+	Layout string
+	// Encoding is used to decode and encode []byte fields, raw when empty
+	Encoding string
+	// Len is used to check the decoded length of []byte fields, when
+	// positive
+	Len int
+	// AllocAlways is used to allocate pointer to struct fields even when
+	// none of their keys or flags is set
+	AllocAlways bool
+
+	// hasOptions is set once an option is parsed, env keys must come first
+	hasOptions bool
+}
+
+// separator returns the separator of slice and map values, | by default.
+func (t tag) separator() string {
+	if t.Separator == "" {
+		return "|"
+	}
+	return t.Separator
 }
 
 // parseTag is used in the Unmarshal function to parse the "env" field tags
 // into a tag struct for use in the set function.
-func parseTag(tagString string) tag {
+//
+// The tag is a comma separated list of env keys followed by key=value
+// options. Option values may contain commas when single quoted, as in
+// default='a,b', or when escaped with a backslash: \, \= \' and \\ stand
+// for the character itself. The struct tag syntax needs the backslash
+// doubled, as in `env:"KEY,desc=host\\, port"`. An empty env key, or one
+// after an option, is an error since it is most likely the tail of an
+// unquoted option value. Nested structs may leave out the env keys to only
+// give options, as in `env:",alloc=always"`.
+func parseTag(tagString string) (tag, error) {
 	var t tag
-	envKeys := strings.Split(tagString, ",")
-	for _, key := range envKeys {
-		if !strings.Contains(key, "=") {
-			t.Keys = append(t.Keys, key)
+	items, err := splitTag(tagString)
+	if err != nil {
+		return t, err
+	}
+	if len(items) > 1 && !items[0].option && items[0].value == "" && items[1].option {
+		items = items[1:]
+	}
+
+	for _, item := range items {
+		if !item.option {
+			if item.value == "" {
+				return t, errors.New("empty env key")
+			}
+			if t.hasOptions {
+				return t, fmt.Errorf("env key %q follows an option; quote the option value or escape its commas", item.value)
+			}
+			t.Keys = append(t.Keys, item.value)
 			continue
 		}
-		keyData := strings.SplitN(key, "=", 2)
-		switch strings.ToLower(keyData[0]) {
+
+		t.hasOptions = true
+		switch strings.ToLower(item.name) {
+		case "":
+			return t, fmt.Errorf("option %q has no name", "="+item.value)
 		case tagKeyDefault:
-			t.Default = keyData[1]
+			t.Default = item.value
 		case tagKeyRequired:
-			t.Required = strings.ToLower(keyData[1]) == "true"
+			t.Required = strings.ToLower(item.value) == "true"
 		case tagKeySeparator:
-			t.Separator = keyData[1]
+			t.Separator = item.value
 		case tagKeyFlag:
-			t.Flag = keyData[1]
+			t.Flag = item.value
 		case tagKeyDesc:
-			t.Desc = keyData[1]
+			t.Desc = item.value
+		case tagKeyReload:
+			t.NoReload = strings.ToLower(item.value) == "false"
+		case tagKeyExpand:
+			t.NoExpand = strings.ToLower(item.value) == "false"
+		case tagKeyLayout:
+			t.Layout = item.value
+			if layout, ok := timeLayouts[item.value]; ok {
+				t.Layout = layout
+			}
+		case tagKeyEncoding:
+			switch item.value {
+			case EncodingRaw, EncodingBase64, EncodingBase64URL, EncodingHex:
+				t.Encoding = item.value
+			default:
+				return t, fmt.Errorf("unknown encoding %q", item.value)
+			}
+		case tagKeyLen:
+			n, err := strconv.Atoi(item.value)
+			if err != nil || n < 0 {
+				return t, fmt.Errorf("option len=%s must be a non-negative integer", item.value)
+			}
+			t.Len = n
+		case tagKeyAlloc:
+			switch strings.ToLower(item.value) {
+			case "always":
+				t.AllocAlways = true
+			case "present":
+				t.AllocAlways = false
+			default:
+				return t, fmt.Errorf("option alloc=%s must be always or present", item.value)
+			}
 		default:
 			// just ignoring unsupported keys
 			continue
 		}
 	}
-	return t
+	return t, nil
+}
+
+// tagItem is a comma separated item of an "env" field tag.
+type tagItem struct {
+	// option is set for name=value items, env keys have only a value
+	option bool
+	name   string
+	value  string
+}
+
+// splitTag splits an "env" field tag into its items, removing quotes and
+// escapes.
+func splitTag(s string) ([]tagItem, error) {
+	var (
+		items  []tagItem
+		item   tagItem
+		b      strings.Builder
+		quoted bool
+		// partStart is the index where the current key or value starts; a
+		// quote only opens there, so apostrophes in text are kept
+		partStart int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(`,='\`, s[i+1]) >= 0:
+			i++
+			b.WriteByte(s[i])
+		case c == '\'' && (quoted || i == partStart):
+			quoted = !quoted
+		case quoted:
+			b.WriteByte(c)
+		case c == ',':
+			item.value = b.String()
+			items = append(items, item)
+			item = tagItem{}
+			b.Reset()
+			partStart = i + 1
+		case c == '=' && !item.option:
+			item.option = true
+			item.name = b.String()
+			b.Reset()
+			partStart = i + 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	item.value = b.String()
+	return append(items, item), nil
 }
@@ -21,7 +21,9 @@ import (
 	"flag"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"testing/quick"
 	"time"
 )
 
@@ -84,7 +86,7 @@ type ValidStruct struct {
 }
 
 type UnsupportedStruct struct {
-	Timestamp time.Time `env:"TIMESTAMP"`
+	Impedance complex128 `env:"IMPEDANCE"`
 }
 
 type UnexportedStruct struct {
@@ -161,6 +163,27 @@ type IterValuesStruct struct {
 	WithSeparator []int           `env:"SEPARATOR,separator=&"`
 }
 
+type RoundTripStruct struct {
+	String        string                `env:"STRING"`
+	Int8          int8                  `env:"INT8"`
+	Uint16        uint16                `env:"UINT16"`
+	Float32       float32               `env:"FLOAT32"`
+	Float64       float64               `env:"FLOAT64"`
+	Bool          bool                  `env:"BOOL"`
+	Duration      time.Duration         `env:"DURATION"`
+	PointerInt    *int                  `env:"POINTER_INT"`
+	StringSlice   []string              `env:"STRING_SLICE"`
+	IntSlice      []int                 `env:"INT_SLICE,separator=&"`
+	DurationSlice []time.Duration       `env:"DURATION_SLICE"`
+	PointerSlice  *[]float64            `env:"POINTER_SLICE"`
+	Map           map[string]int        `env:"MAP,separator=;"`
+	Base64Slice   []Base64EncodedString `env:"BASE64_SLICE"`
+
+	Nested struct {
+		Bools []bool `env:"NESTED_BOOLS"`
+	}
+}
+
 const testEnvFlagSetName = "test-env-flags"
 
 func TestUnmarshal(t *testing.T) {
@@ -345,7 +368,7 @@ func TestUnmarshalInvalid(t *testing.T) {
 func TestUnmarshalUnsupported(t *testing.T) {
 	t.Parallel()
 	var (
-		environ           = map[string]string{"TIMESTAMP": "2016-07-15T12:00:00.000Z"}
+		environ           = map[string]string{"IMPEDANCE": "3+4i"}
 		unsupportedStruct UnsupportedStruct
 		flags             = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
 	)
@@ -431,6 +454,58 @@ func TestUnmarshalSlice(t *testing.T) {
 	}
 }
 
+type ArrayStruct struct {
+	Coordinates [3]float64        `env:"COORDINATES"`
+	Hosts       [2]string         `env:"HOSTS,separator=','"`
+	Timeouts    *[2]time.Duration `env:"TIMEOUTS"`
+	Key         [4]byte           `env:"KEY,encoding=hex"`
+	Empty       [0]int            `env:"EMPTY"`
+}
+
+func TestUnmarshalArray(t *testing.T) {
+	t.Parallel()
+	var (
+		environ = map[string]string{
+			"COORDINATES": "1.5|-2|3e2",
+			"HOSTS":       "a.example.com,b.example.com",
+			"TIMEOUTS":    "1s|2m",
+			"KEY":         "0102feff",
+			"EMPTY":       "",
+		}
+		arrayStruct ArrayStruct
+		flags       = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	)
+
+	if err := Unmarshal(flags, environ, &arrayStruct); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	expected := ArrayStruct{
+		Coordinates: [3]float64{1.5, -2, 300},
+		Hosts:       [2]string{"a.example.com", "b.example.com"},
+		Timeouts:    &[2]time.Duration{time.Second, 2 * time.Minute},
+		Key:         [4]byte{1, 2, 0xfe, 0xff},
+	}
+	if !reflect.DeepEqual(arrayStruct, expected) {
+		t.Errorf("Expected '%+v' but got '%+v'", expected, arrayStruct)
+	}
+
+	tests := map[string]string{
+		"COORDINATES=1|2":     "expected 3 elements, got 2",
+		"COORDINATES=1|2|3|4": "expected 3 elements, got 4",
+		"COORDINATES=":        "expected 3 elements, got 0",
+		"COORDINATES=1|x|3":   `parsing "x": invalid syntax`,
+		"KEY=010203":          "decoded value is 3 bytes long, expected 4",
+		"EMPTY=1":             "expected 0 elements, got 1",
+	}
+	for assignment, reason := range tests {
+		key, value, _ := strings.Cut(assignment, "=")
+		err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError), EnvSet{key: value}, &arrayStruct)
+		if err == nil || !strings.Contains(err.Error(), reason) {
+			t.Errorf("%s: Expected error '%s' but got '%v'", assignment, reason, err)
+		}
+	}
+}
+
 func TestUnmarshalDefaultValues(t *testing.T) {
 	t.Parallel()
 	var (
@@ -485,7 +560,7 @@ func TestUnmarshalRequiredValues(t *testing.T) {
 
 	// Fill REQUIRED_VAL and retry REQUIRED_VAL_MORE
 	environ["REQUIRED_VAL"] = "required"
-	err = Unmarshal(flags, environ, &requiredValuesStruct)
+	err = Unmarshal(flags, environ, &requiredValuesStruct, PreserveEnvSet())
 	if err == nil {
 		t.Errorf("Expected error 'ErrMissingRequiredValue' but got '%s'", err)
 	}
@@ -686,3 +761,557 @@ func TestMarshalCustom(t *testing.T) {
 		t.Errorf("Expected field value to be '%s' but got '%s'", `{"someField":43}`, v)
 	}
 }
+
+func TestMarshalSlice(t *testing.T) {
+	t.Parallel()
+	validStruct := RoundTripStruct{
+		StringSlice:  []string{"separate", "values"},
+		IntSlice:     []int{1, 2},
+		PointerSlice: &[]float64{1.5, 2},
+		Map:          map[string]int{"b": 2, "a": 1},
+		Base64Slice:  []Base64EncodedString{"a", "b"},
+	}
+
+	es, err := Marshal(&validStruct)
+	if err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+
+	testCases := [][]string{
+		{es["STRING_SLICE"], "separate|values"},
+		{es["INT_SLICE"], "1&2"},
+		{es["POINTER_SLICE"], "1.5|2"},
+		{es["MAP"], "a=1;b=2"},
+		{es["BASE64_SLICE"], "YQ==|Yg=="},
+	}
+	for _, testCase := range testCases {
+		if testCase[0] != testCase[1] {
+			t.Errorf("Expected field value to be '%s' but got '%s'", testCase[1], testCase[0])
+		}
+	}
+
+	if v, ok := es["DURATION_SLICE"]; ok {
+		t.Errorf("Expected field '%s' to not exist but got '%s'", "DURATION_SLICE", v)
+	}
+}
+
+func TestMarshalArray(t *testing.T) {
+	t.Parallel()
+	arrayStruct := ArrayStruct{
+		Coordinates: [3]float64{1.5, -2, 300},
+		Hosts:       [2]string{"a.example.com", ""},
+		Key:         [4]byte{1, 2, 0xfe, 0xff},
+	}
+
+	es, err := Marshal(&arrayStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := EnvSet{
+		"COORDINATES": "1.5|-2|300",
+		"HOSTS":       "a.example.com,",
+		"KEY":         "0102feff",
+		"EMPTY":       "",
+	}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected '%v' but got '%v'", expected, es)
+	}
+
+	var roundTrip ArrayStruct
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError), es, &roundTrip); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if !reflect.DeepEqual(roundTrip, arrayStruct) {
+		t.Errorf("Expected '%+v' to round trip but got '%+v'", arrayStruct, roundTrip)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	t.Parallel()
+	roundTrip := func(in RoundTripStruct) bool {
+		// values Unmarshal would read differently must fail to marshal
+		es, err := Marshal(&in)
+		if err != nil {
+			return !representable(in)
+		}
+
+		var (
+			out   RoundTripStruct
+			flags = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+		)
+		if err := Unmarshal(flags, es, &out); err != nil {
+			t.Logf("Expected no error but got '%s'", err)
+			return false
+		}
+		if !reflect.DeepEqual(in, out) {
+			t.Logf("Expected '%+v' but got '%+v'", in, out)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// representable reports whether every slice and map of in can be joined
+// without ambiguity, see JoinValues and JoinEntries.
+func representable(in RoundTripStruct) bool {
+	lists := [][]string{in.StringSlice}
+	var base64Slice []string
+	for _, s := range in.Base64Slice {
+		base64Slice = append(base64Slice, base64.StdEncoding.EncodeToString([]byte(s)))
+	}
+	lists = append(lists, base64Slice)
+	for _, list := range lists {
+		if len(list) == 1 && list[0] == "" {
+			return false
+		}
+		for _, s := range list {
+			if strings.Contains(s, "|") {
+				return false
+			}
+		}
+	}
+	for k := range in.Map {
+		if strings.ContainsAny(k, "=;") {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMarshalUnrepresentable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		value  interface{}
+		reason string
+	}{
+		{"separator in element", &struct {
+			Hosts []string `env:"HOSTS"`
+		}{Hosts: []string{"a|b", ""}}, `element 0 "a|b" contains the separator "|"`},
+		{"custom separator in element", &struct {
+			Hosts [2]string `env:"HOSTS,separator=;"`
+		}{Hosts: [2]string{"a", "b;c"}}, `element 1 "b;c" contains the separator ";"`},
+		{"single empty element", &struct {
+			Hosts []string `env:"HOSTS"`
+		}{Hosts: []string{""}}, "a single empty element"},
+		{"equals in map key", &struct {
+			Limits map[string]string `env:"LIMITS"`
+		}{Limits: map[string]string{"k=x": "v"}}, `map key "k=x" contains =`},
+		{"separator in map key", &struct {
+			Limits map[string]string `env:"LIMITS"`
+		}{Limits: map[string]string{"a|b": "v"}}, `map key "a|b" contains = or the separator "|"`},
+		{"separator in map value", &struct {
+			Limits map[string]string `env:"LIMITS"`
+		}{Limits: map[string]string{"k": "a|b"}}, `value "a|b" of map key "k" contains the separator "|"`},
+	}
+	for _, tt := range tests {
+		if _, err := Marshal(tt.value); err == nil || !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("%s: Expected error '%s' but got '%v'", tt.name, tt.reason, err)
+		}
+	}
+
+	// values holding = and empty elements among others are fine
+	ok := struct {
+		Hosts  []string          `env:"HOSTS"`
+		Limits map[string]string `env:"LIMITS"`
+	}{Hosts: []string{"a=b", ""}, Limits: map[string]string{"k": "x=v", "": ""}}
+	es, err := Marshal(&ok)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if expected := (EnvSet{"HOSTS": "a=b|", "LIMITS": "=|k=x=v"}); !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected '%v' but got '%v'", expected, es)
+	}
+}
+
+func TestUnmarshalConsume(t *testing.T) {
+	t.Parallel()
+	newEnviron := func() map[string]string {
+		return map[string]string{
+			"PRESENT":          "youFoundMe",
+			"MISSING_2":        "alias",
+			"MISSING_STRING":   "found",
+			"EXTRA":            "extra",
+			"npm_config_cache": "first",
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		opts      []Option
+		remaining []string
+	}{
+		{"default", nil, []string{"EXTRA", "npm_config_cache"}},
+		{"all keys", []Option{ConsumeAllKeys()}, []string{"EXTRA", "npm_config_cache"}},
+		{"preserve", []Option{PreserveEnvSet()}, []string{"EXTRA", "MISSING_2", "MISSING_STRING", "PRESENT", "npm_config_cache"}},
+	}
+	for _, testCase := range testCases {
+		var (
+			environ            = newEnviron()
+			defaultValueStruct DefaultValueStruct
+			flags              = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+		)
+
+		if err := Unmarshal(flags, environ, &defaultValueStruct, testCase.opts...); err != nil {
+			t.Errorf("Expected no error but got '%s'", err)
+		}
+
+		if remaining := sortedKeys(environ); !reflect.DeepEqual(remaining, testCase.remaining) {
+			t.Errorf("Expected remaining keys with %s to be '%v' but got '%v'", testCase.name, testCase.remaining, remaining)
+		}
+	}
+}
+
+func TestUnmarshalConsumeAlias(t *testing.T) {
+	t.Parallel()
+	var (
+		environ = map[string]string{
+			"npm_config_cache": "first",
+			"NPM_CONFIG_CACHE": "second",
+		}
+		validStruct ValidStruct
+		flags       = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	)
+
+	if err := Unmarshal(flags, environ, &validStruct); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+	if remaining := sortedKeys(environ); !reflect.DeepEqual(remaining, []string{"NPM_CONFIG_CACHE"}) {
+		t.Errorf("Expected remaining keys to be '%v' but got '%v'", []string{"NPM_CONFIG_CACHE"}, remaining)
+	}
+
+	environ["npm_config_cache"] = "first"
+	if err := Unmarshal(flags, environ, &validStruct, ConsumeAllKeys()); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+	if len(environ) != 0 {
+		t.Errorf("Expected no remaining keys but got '%v'", environ)
+	}
+}
+
+func TestUnmarshalAllErrors(t *testing.T) {
+	t.Parallel()
+	var (
+		environ = map[string]string{
+			"MISSING_INT":  "seven",
+			"MISSING_UINT": "-1",
+		}
+		defaultValueStruct DefaultValueStruct
+		flags              = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	)
+
+	err := Unmarshal(flags, environ, &defaultValueStruct, AllErrors())
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	for _, key := range []string{"[MISSING_INT]", "[MISSING_UINT]"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error to name %s but got '%s'", key, err)
+		}
+	}
+	if defaultValueStruct.DefaultFloat64 != 10.11 {
+		t.Errorf("Expected fields after the failures to be set but got '%v'", defaultValueStruct.DefaultFloat64)
+	}
+
+	var requiredValueStruct RequiredValueStruct
+	err = Unmarshal(flags, EnvSet{}, &requiredValueStruct, AllErrors())
+	var errMissing *ErrMissingRequiredValue
+	if !errors.As(err, &errMissing) || !strings.Contains(err.Error(), "REQUIRED_VAL_MORE") {
+		t.Errorf("Expected both required values to be reported but got '%v'", err)
+	}
+}
+
+func TestUnmarshalCaseInsensitive(t *testing.T) {
+	t.Parallel()
+	type CaseStruct struct {
+		Path  string `env:"PATH"`
+		Home  string `env:"HOME"`
+		Mixed string `env:"Mixed_Case"`
+		URL   string `env:"URL,default=http://${host}:80"`
+	}
+	newEnviron := func() EnvSet {
+		return EnvSet{"path": "/usr/bin", "Path": "/bin", "home": "/root", "MIXED_CASE": "upper", "Mixed_Case": "exact", "HOST": "example.com"}
+	}
+
+	var caseStruct CaseStruct
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), newEnviron(), &caseStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if caseStruct.Path != "" || caseStruct.Home != "" {
+		t.Errorf("Expected keys to match exactly by default but got '%+v'", caseStruct)
+	}
+
+	testCases := []struct {
+		name      string
+		opts      []Option
+		remaining []string
+	}{
+		{"default", nil, []string{"HOST", "MIXED_CASE", "path"}},
+		{"all keys", []Option{ConsumeAllKeys()}, []string{"HOST"}},
+	}
+	for _, testCase := range testCases {
+		var (
+			environ    = newEnviron()
+			caseStruct CaseStruct
+			warnings   []string
+		)
+		opts := append([]Option{CaseInsensitive(func(key string, matches []string) {
+			warnings = append(warnings, key+": "+strings.Join(matches, ", "))
+		})}, testCase.opts...)
+		if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), environ, &caseStruct, opts...); err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+
+		expected := CaseStruct{Path: "/bin", Home: "/root", Mixed: "exact", URL: "http://example.com:80"}
+		if caseStruct != expected {
+			t.Errorf("Expected '%+v' with %s but got '%+v'", expected, testCase.name, caseStruct)
+		}
+		if want := []string{"PATH: Path, path", "Mixed_Case: Mixed_Case, MIXED_CASE"}; !reflect.DeepEqual(warnings, want) {
+			t.Errorf("Expected warnings '%v' with %s but got '%v'", want, testCase.name, warnings)
+		}
+		if remaining := sortedKeys(environ); !reflect.DeepEqual(remaining, testCase.remaining) {
+			t.Errorf("Expected remaining keys with %s to be '%v' but got '%v'", testCase.name, testCase.remaining, remaining)
+		}
+	}
+
+	type PortStruct struct {
+		Port int `env:"APP_PORT"`
+	}
+	es := EnvSet{"app_port": "80", "app_prot": "81"}
+	err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), es, &PortStruct{}, CaseInsensitive(nil), Strict("APP_"))
+	var errUnknown *ErrUnknownKey
+	if !errors.As(err, &errUnknown) || errUnknown.Key != "app_prot" || errUnknown.Suggestion != "APP_PORT" {
+		t.Errorf("Expected error 'ErrUnknownKey' for app_prot but got '%v'", err)
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		tag      string
+		expected tag
+	}{
+		{`A,B`, tag{Keys: []string{"A", "B"}}},
+		{`A,default=key=value`, tag{Keys: []string{"A"}, Default: "key=value", hasOptions: true}},
+		{`A,default='a,b',separator=','`, tag{Keys: []string{"A"}, Default: "a,b", Separator: ",", hasOptions: true}},
+		{`A,desc=Host\, port and path`, tag{Keys: []string{"A"}, Desc: "Host, port and path", hasOptions: true}},
+		{`A,desc=it's \'quoted\' \\ here`, tag{Keys: []string{"A"}, Desc: `it's 'quoted' \ here`, hasOptions: true}},
+		{`A,default=C:\temp`, tag{Keys: []string{"A"}, Default: `C:\temp`, hasOptions: true}},
+		{`A,default=''`, tag{Keys: []string{"A"}, hasOptions: true}},
+		{`A,invalid=invalid,required=true`, tag{Keys: []string{"A"}, Required: true, hasOptions: true}},
+		{`,alloc=always`, tag{AllocAlways: true, hasOptions: true}},
+	}
+	for _, tt := range tests {
+		got, err := parseTag(tt.tag)
+		if err != nil {
+			t.Errorf("%s: Expected no error but got '%s'", tt.tag, err)
+		} else if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: Expected tag to be '%+v' but got '%+v'", tt.tag, tt.expected, got)
+		}
+	}
+}
+
+func TestParseTagInvalid(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		`A,default=a,b`:     `env key "b" follows an option`,
+		`A,separator=,`:     "empty env key",
+		`A,,B`:              "empty env key",
+		`A,`:                "empty env key",
+		`A,=value`:          "has no name",
+		`A,default='a,b`:    "unterminated quote",
+		`A,desc=x,B,C`:      `env key "B" follows an option`,
+		`A,flag=x,default`:  `env key "default" follows an option`,
+		`A,encoding=base32`: `unknown encoding "base32"`,
+		`A,len=-1`:          "must be a non-negative integer",
+		`A,len=32B`:         "must be a non-negative integer",
+		`A,alloc=sometimes`: "must be always or present",
+		`,`:                 "empty env key",
+	}
+	for tagString, reason := range tests {
+		if _, err := parseTag(tagString); err == nil || !strings.Contains(err.Error(), reason) {
+			t.Errorf("%s: Expected error '%s' but got '%v'", tagString, reason, err)
+		}
+	}
+}
+
+type TLSConfig struct {
+	Cert string `env:"TLS_CERT,required=true"`
+	Key  string `env:"TLS_KEY,flag=tls-key-file"`
+	// MinVersion is ignored while TLS is nil
+	MinVersion string `env:"TLS_MIN_VERSION,default=1.2"`
+}
+
+type PoolConfig struct {
+	Size int `env:"POOL_SIZE,default=10"`
+}
+
+type LimitsConfig struct {
+	Rate int `env:"RATE_LIMIT"`
+}
+
+type PtrStruct struct {
+	TLS    *TLSConfig
+	Pool   *PoolConfig `env:",alloc=always"`
+	Nested struct {
+		Limits *LimitsConfig
+	}
+	// Next is not followed, PtrStruct is already being traversed
+	Next *PtrStruct
+}
+
+func TestUnmarshalNestedPointer(t *testing.T) {
+	t.Parallel()
+	var ptrStruct PtrStruct
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), EnvSet{}, &ptrStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := PtrStruct{Pool: &PoolConfig{Size: 10}}
+	if !reflect.DeepEqual(ptrStruct, expected) {
+		t.Errorf("Expected '%+v' but got '%+v'", expected, ptrStruct)
+	}
+
+	flags, err := RegisterFlags(&ptrStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := flags.Parse([]string{"-tls-key-file", "key.pem"}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	es := EnvSet{"TLS_CERT": "cert.pem", "POOL_SIZE": "3", "RATE_LIMIT": "100"}
+	if err := Unmarshal(flags, es, &ptrStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected = PtrStruct{
+		TLS:  &TLSConfig{Cert: "cert.pem", Key: "key.pem", MinVersion: "1.2"},
+		Pool: &PoolConfig{Size: 3},
+	}
+	expected.Nested.Limits = &LimitsConfig{Rate: 100}
+	if !reflect.DeepEqual(ptrStruct, expected) {
+		t.Errorf("Expected '%+v' but got '%+v'", expected, ptrStruct)
+	}
+
+	// a key of a field below the pointer is enough, required ones then apply
+	err = Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), EnvSet{"TLS_MIN_VERSION": "1.3"}, &PtrStruct{})
+	var errMissing *ErrMissingRequiredValue
+	if !errors.As(err, &errMissing) || errMissing.Value != "TLS_CERT" {
+		t.Errorf("Expected error 'ErrMissingRequiredValue' for TLS_CERT but got '%v'", err)
+	}
+}
+
+func TestMarshalNestedPointer(t *testing.T) {
+	t.Parallel()
+	ptrStruct := PtrStruct{TLS: &TLSConfig{Cert: "cert.pem"}}
+	es, err := Marshal(&ptrStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := EnvSet{"TLS_CERT": "cert.pem", "TLS_KEY": "", "TLS_MIN_VERSION": ""}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected '%v' but got '%v'", expected, es)
+	}
+
+	args, err := MarshalFlags(&ptrStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if want := []string{"-tls-cert=cert.pem", "-tls-key-file=", "-tls-min-version="}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected '%v' but got '%v'", want, args)
+	}
+}
+
+type Backend interface {
+	Name() string
+}
+
+type RedisBackend struct {
+	Addr string `env:"REDIS_ADDR,default=localhost:6379"`
+}
+
+func (*RedisBackend) Name() string { return "redis" }
+
+type IfaceStruct struct {
+	Backend Backend
+	Other   interface{}
+	Debug   bool `env:"DEBUG"`
+}
+
+func TestUnmarshalInterface(t *testing.T) {
+	t.Parallel()
+	ifaceStruct := IfaceStruct{Backend: &RedisBackend{}, Other: "not a struct"}
+	flags, err := RegisterFlags(&ifaceStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if flags.Lookup("redis-addr") == nil {
+		t.Errorf("Expected a flag for the field of the backend")
+	}
+	es := EnvSet{"REDIS_ADDR": "cache:6379", "DEBUG": "true"}
+	if err := Unmarshal(flags, es, &ifaceStruct, Strict("REDIS_")); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if addr := ifaceStruct.Backend.(*RedisBackend).Addr; addr != "cache:6379" || !ifaceStruct.Debug {
+		t.Errorf("Expected backend address 'cache:6379' but got '%s'", addr)
+	}
+	if len(es) != 0 {
+		t.Errorf("Expected the keys to be consumed but got '%v'", es)
+	}
+
+	marshalled, err := Marshal(&ifaceStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if expected := (EnvSet{"REDIS_ADDR": "cache:6379", "DEBUG": "true"}); !reflect.DeepEqual(marshalled, expected) {
+		t.Errorf("Expected '%v' but got '%v'", expected, marshalled)
+	}
+
+	// a nil interface is left alone
+	var empty IfaceStruct
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), EnvSet{"REDIS_ADDR": "x"}, &empty); err != nil || empty.Backend != nil {
+		t.Errorf("Expected a nil backend and no error but got '%v', '%v'", empty.Backend, err)
+	}
+}
+
+func TestUnmarshalInvalidTag(t *testing.T) {
+	t.Parallel()
+	type InvalidTagStruct struct {
+		Nested struct {
+			Hosts []string `env:"HOSTS,default=a,b"`
+		}
+	}
+
+	var (
+		invalidTagStruct InvalidTagStruct
+		errTag           *ErrInvalidTag
+	)
+	err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError), EnvSet{}, &invalidTagStruct)
+	if !errors.As(err, &errTag) || errTag.Field != "Nested.Hosts" {
+		t.Errorf("Expected error 'ErrInvalidTag' for Nested.Hosts but got '%v'", err)
+	}
+	if _, err := Marshal(&invalidTagStruct); !errors.As(err, &errTag) {
+		t.Errorf("Expected error 'ErrInvalidTag' but got '%v'", err)
+	}
+	if _, err := RegisterFlags(&invalidTagStruct); !errors.As(err, &errTag) {
+		t.Errorf("Expected error 'ErrInvalidTag' but got '%v'", err)
+	}
+
+	type KeylessStruct struct {
+		Name string `env:",default=x"`
+	}
+	err = Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError), EnvSet{}, &KeylessStruct{})
+	if !errors.As(err, &errTag) || !strings.Contains(err.Error(), "missing env key") {
+		t.Errorf("Expected error 'ErrInvalidTag' for a missing key but got '%v'", err)
+	}
+
+	type AllocStruct struct {
+		Names []string `env:"NAMES,alloc=always"`
+	}
+	err = Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError), EnvSet{}, &AllocStruct{})
+	if !errors.As(err, &errTag) || !strings.Contains(err.Error(), "only applies to pointers to structs") {
+		t.Errorf("Expected error 'ErrInvalidTag' for alloc but got '%v'", err)
+	}
+}
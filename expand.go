@@ -0,0 +1,161 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrExpansionCycle returned when variables reference each other in a loop,
+// such as A=${B} and B=${A}.
+type ErrExpansionCycle struct {
+	// Cycle lists the variables of the loop, the first one repeated last
+	Cycle []string
+}
+
+func (e ErrExpansionCycle) Error() string {
+	return fmt.Sprintf("variable expansion cycle %s", strings.Join(e.Cycle, " -> "))
+}
+
+// expander expands ${VAR} references against variables looked up by name.
+// Referenced values are expanded in turn.
+type expander struct {
+	lookup func(name string) (string, bool)
+	// stack holds the variables being expanded, to detect cycles
+	stack []string
+}
+
+// expand returns s with ${VAR}, ${VAR:-fallback} and ${VAR:?message}
+// replaced and $$ turned into $. A $ starting anything else is kept. VAR
+// must be a shell variable name, other ${...} forms are errors.
+func (x *expander) expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := matchingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated %q", s[i:])
+			}
+			value, err := x.reference(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// reference expands the body of a ${...} reference.
+func (x *expander) reference(body string) (string, error) {
+	name, op, word := body, "", ""
+	if i := strings.IndexByte(body, ':'); i >= 0 {
+		name, op, word = body[:i], body[i:min(i+2, len(body))], body[min(i+2, len(body)):]
+	}
+	if name == "" {
+		return "", fmt.Errorf("empty variable name in ${%s}", body)
+	}
+	if !isShellName(name) {
+		return "", fmt.Errorf("unsupported expansion ${%s}", body)
+	}
+
+	value, err := x.variable(name)
+	if err != nil {
+		return "", err
+	}
+	switch op {
+	case "":
+		return value, nil
+	case ":-":
+		if value == "" {
+			return x.expand(word)
+		}
+		return value, nil
+	case ":?":
+		if value != "" {
+			return value, nil
+		}
+		message, err := x.expand(word)
+		if err != nil {
+			return "", err
+		}
+		if message == "" {
+			message = "parameter null or not set"
+		}
+		return "", fmt.Errorf("%s: %s", name, message)
+	default:
+		return "", fmt.Errorf("unsupported expansion ${%s}", body)
+	}
+}
+
+// variable returns the expanded value of name, empty when it is not set.
+func (x *expander) variable(name string) (string, error) {
+	for i, seen := range x.stack {
+		if seen == name {
+			cycle := append(append([]string{}, x.stack[i:]...), name)
+			return "", &ErrExpansionCycle{Cycle: cycle}
+		}
+	}
+
+	value, ok := x.lookup(name)
+	if !ok {
+		return "", nil
+	}
+	x.stack = append(x.stack, name)
+	defer func() { x.stack = x.stack[:len(x.stack)-1] }()
+	return x.expand(value)
+}
+
+// matchingBrace returns the index of the } closing a reference whose body
+// starts at start, skipping nested references, or -1.
+func matchingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// escapeExpansion escapes value so that expanding it gives value back.
+func escapeExpansion(value string) string {
+	return strings.ReplaceAll(value, "$", "$$")
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type ExpandStruct struct {
+	User     string `env:"DB_USER"`
+	Host     string `env:"DB_HOST"`
+	URL      string `env:"DATABASE_URL"`
+	Cache    string `env:"CACHE_DIR,default=${HOME}/.cache"`
+	Password string `env:"DB_PASSWORD,expand=false"`
+	Price    string `env:"PRICE"`
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+	vars := EnvSet{
+		"HOST":  "db",
+		"PORT":  "",
+		"URL":   "postgres://${HOST}:${PORT:-5432}",
+		"NAME":  "${NAME}",
+		"LOOP1": "${LOOP2}",
+		"LOOP2": "x${LOOP1}",
+	}
+	tests := []struct {
+		in, out string
+		err     string
+	}{
+		{in: "plain", out: "plain"},
+		{in: "${HOST}", out: "db"},
+		{in: "${MISSING}", out: ""},
+		{in: "${PORT:-5432}", out: "5432"},
+		{in: "${MISSING:-${HOST}-fallback}", out: "db-fallback"},
+		{in: "${HOST:-unused}", out: "db"},
+		{in: "${URL}/app", out: "postgres://db:5432/app"},
+		{in: "$$HOST costs $5 and $$$$", out: "$HOST costs $5 and $$"},
+		{in: "trailing $", out: "trailing $"},
+		{in: "${HOST:?must be set}", out: "db"},
+		{in: "${PORT:?must be set}", err: "PORT: must be set"},
+		{in: "${PORT:?}", err: "PORT: parameter null or not set"},
+		{in: "${HOST", err: "unterminated"},
+		{in: "${}", err: "empty variable name"},
+		{in: "${HOST:=x}", err: "unsupported expansion"},
+		{in: "${HOST-x}", err: "unsupported expansion ${HOST-x}"},
+		{in: "${HOST+x}", err: "unsupported expansion"},
+		{in: "${A.B}", err: "unsupported expansion"},
+		{in: "${1HOST}", err: "unsupported expansion"},
+		{in: "${_HOST_2:-x}", out: "x"},
+		{in: "${NAME}", err: "cycle NAME -> NAME"},
+		{in: "${LOOP1}", err: "cycle LOOP1 -> LOOP2 -> LOOP1"},
+	}
+
+	for _, tt := range tests {
+		x := &expander{lookup: func(name string) (string, bool) {
+			v, ok := vars[name]
+			return v, ok
+		}}
+		out, err := x.expand(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: Expected error '%s' but got '%v'", tt.in, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Expected no error but got '%s'", tt.in, err)
+		} else if out != tt.out {
+			t.Errorf("%s: Expected '%s' but got '%s'", tt.in, tt.out, out)
+		}
+	}
+}
+
+func TestUnmarshalExpand(t *testing.T) {
+	t.Parallel()
+	var (
+		environ = EnvSet{
+			"HOME":         "/home/test",
+			"DB_USER":      "admin",
+			"DB_HOST":      "${REGION}.db",
+			"REGION":       "eu",
+			"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST}:${DB_PORT:-5432}/app",
+			"DB_PASSWORD":  "pa$${word}",
+			"PRICE":        "$$5",
+		}
+		expandStruct ExpandStruct
+		flags        = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	)
+
+	if err := Unmarshal(flags, environ, &expandStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	// DB_USER and DB_HOST are consumed before DATABASE_URL references them
+	expected := ExpandStruct{
+		User:     "admin",
+		Host:     "eu.db",
+		URL:      "postgres://admin@eu.db:5432/app",
+		Cache:    "/home/test/.cache",
+		Password: "pa$${word}",
+		Price:    "$5",
+	}
+	if expandStruct != expected {
+		t.Errorf("Expected struct to be '%+v' but got '%+v'", expected, expandStruct)
+	}
+}
+
+func TestUnmarshalExpandErrors(t *testing.T) {
+	t.Parallel()
+	var (
+		expandStruct ExpandStruct
+		flags        = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+		errCycle     *ErrExpansionCycle
+	)
+
+	err := Unmarshal(flags, EnvSet{"DB_HOST": "${DATABASE_URL}", "DATABASE_URL": "${DB_HOST}"}, &expandStruct)
+	if !errors.As(err, &errCycle) || !reflect.DeepEqual(errCycle.Cycle, []string{"DB_HOST", "DATABASE_URL", "DB_HOST"}) {
+		t.Errorf("Expected error 'ErrExpansionCycle' but got '%v'", err)
+	}
+
+	err = Unmarshal(flags, EnvSet{"DB_USER": "${USER:?is required}"}, &expandStruct)
+	if err == nil || err.Error() != "expanding [DB_USER]: USER: is required" {
+		t.Errorf("Expected an error naming DB_USER but got '%v'", err)
+	}
+}
+
+func TestUnmarshalExpandFlags(t *testing.T) {
+	t.Parallel()
+	var expandStruct ExpandStruct
+	flags, err := RegisterFlags(&expandStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := flags.Parse([]string{"-price", "${HOME}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Unmarshal(flags, EnvSet{"HOME": "/home/test"}, &expandStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if expandStruct.Price != "${HOME}" {
+		t.Errorf("Expected flag value to be taken as is but got '%s'", expandStruct.Price)
+	}
+}
+
+func TestMarshalExpandRoundTrip(t *testing.T) {
+	t.Parallel()
+	original := ExpandStruct{
+		URL:      "postgres://${USER}@host",
+		Password: "pa$${word}",
+		Price:    "$5",
+	}
+	es, err := Marshal(&original)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if es["PRICE"] != "$$5" || es["DB_PASSWORD"] != "pa$${word}" {
+		t.Errorf("Expected only expanded fields to be escaped but got '%v'", es)
+	}
+
+	var decoded ExpandStruct
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError), es, &decoded); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	decoded.Cache = original.Cache
+	if decoded != original {
+		t.Errorf("Expected struct to be '%+v' but got '%+v'", original, decoded)
+	}
+}
@@ -23,6 +23,13 @@ import (
 
 const flagSetName = "env-flags"
 
+// EnvFlagRegisterer is implemented by config structs with generated flag
+// registration code, see cmd/envgen. RegisterFlags calls RegisterEnvFlags
+// instead of walking the struct with reflection.
+type EnvFlagRegisterer interface {
+	RegisterEnvFlags(flags *flag.FlagSet) error
+}
+
 func RegisterFlags(v interface{}) (*flag.FlagSet, error) {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
@@ -36,54 +43,220 @@ func RegisterFlags(v interface{}) (*flag.FlagSet, error) {
 
 	flags := flag.NewFlagSet(flagSetName, flag.ExitOnError)
 
-	t := rv.Type()
+	if r, ok := v.(EnvFlagRegisterer); ok {
+		if err := r.RegisterEnvFlags(flags); err != nil {
+			return nil, err
+		}
+		return flags, nil
+	}
 
-	if err := registerStructFlags(flags, t, rv); err != nil {
+	if err := registerStructFlags(flags, rv); err != nil {
 		return nil, err
 	}
 
 	return flags, nil
 }
 
-func registerStructFlags(flags *flag.FlagSet, t reflect.Type, rv reflect.Value) error {
-	for i := range rv.NumField() {
-		valueField := rv.Field(i)
-		typeField := t.Field(i)
+// registerStructFlags registers the flags of the fields of the struct rv,
+// then those of the structs its interface fields point to.
+func registerStructFlags(flags *flag.FlagSet, rv reflect.Value) error {
+	p, err := planOf(rv.Type())
+	if err != nil {
+		return err
+	}
 
-		if valueField.Kind() == reflect.Struct {
-			if !valueField.Addr().CanInterface() {
-				continue
-			}
-			if err := registerStructFlags(flags, typeField.Type, valueField); err != nil {
-				return err
+	for _, field := range p.fields {
+		for _, flagName := range field.Flags {
+			// the plans of interface values are separate, their flags may
+			// already be registered
+			if flags.Lookup(flagName) == nil {
+				flags.String(flagName, field.Tag.Default, field.description())
 			}
+		}
+	}
+
+	for _, sv := range p.ifaceStructs(rv) {
+		if err := registerStructFlags(flags, sv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalFlagsOption configures MarshalFlags.
+type MarshalFlagsOption func(*marshalFlagsOptions)
+
+type marshalFlagsOptions struct {
+	omitDefaults bool
+}
+
+// OmitDefaults makes MarshalFlags skip fields whose value is the one
+// Unmarshal would produce without any flag or environment variable, that is
+// the tag default or, without one, the zero value. A default referencing
+// variables depends on the environment Unmarshal runs in, so fields with one
+// are always kept.
+func OmitDefaults() MarshalFlagsOption {
+	return func(o *marshalFlagsOptions) {
+		o.omitDefaults = true
+	}
+}
+
+// MarshalFlags returns the command line arguments that reproduce v when
+// parsed by the flag set of RegisterFlags, in the form -flag=value. If v is
+// nil or not a pointer to a struct, MarshalFlags returns an ErrInvalidValue.
+//
+// The flag of a field is the first one RegisterFlags registers for it: its
+// custom flag name, or else the one generated from its first env key that no
+// earlier field claimed. Fields without a flag are skipped. Values are
+// formatted like Marshal formats them and nil pointers, slices and maps are
+// omitted.
+//
+// Unmarshal reads the key flags of a field even when another field
+// registered them. MarshalFlags returns an error rather than arguments from
+// which a field would read another field's value.
+func MarshalFlags(v interface{}, opts ...MarshalFlagsOption) ([]string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, ErrInvalidValue
+	}
+
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrInvalidValue
+	}
+
+	var o marshalFlagsOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var reads []flagRead
+	args, err := appendStructArgs(nil, &reads, rv, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFlagReads(args, reads); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// flagRead is a field visited by MarshalFlags, with its value or an invalid
+// Value when it is nil or below a nil pointer.
+type flagRead struct {
+	field field
+	value reflect.Value
+}
+
+func appendStructArgs(args []string, reads *[]flagRead, rv reflect.Value, o marshalFlagsOptions) ([]string, error) {
+	p, err := planOf(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range p.fields {
+		// tagged structs have no flag, see RegisterFlags
+		if field.Type.Kind() == reflect.Struct && !isStdType(field.Type) {
 			continue
 		}
 
-		tag := typeField.Tag.Get("env")
-		if tag == "" {
+		if !field.Exported {
+			return nil, ErrUnexportedField
+		}
+
+		valueField, err := rv.FieldByIndexErr(field.Index)
+		if err != nil {
+			// below a nil pointer to struct
+			valueField = reflect.Value{}
+		} else {
+			switch valueField.Kind() {
+			case reflect.Ptr, reflect.Slice, reflect.Map:
+				if valueField.IsNil() {
+					valueField = reflect.Value{}
+				}
+			}
+		}
+		*reads = append(*reads, flagRead{field: field, value: valueField})
+
+		// the flags RegisterFlags registered for the field, key flags
+		// claimed by an earlier field left out
+		if len(field.Flags) == 0 || !valueField.IsValid() {
 			continue
 		}
+		envTag := field.Tag
+		flagName := field.Flags[0]
 
-		envTag := parseTag(tag)
-		flagName := envTag.Flag
-		description := generateDescription(envTag)
+		value, err := format(valueField, envTag)
+		if err != nil {
+			return nil, err
+		}
 
-		if flagName != "" {
-			flags.String(flagName, envTag.Default, description)
+		// a default expanding variables is only known once Unmarshal runs
+		expands := !envTag.NoExpand && strings.Contains(envTag.Default, "$")
+		if o.omitDefaults && !expands {
+			defaultValue := reflect.New(field.Type).Elem()
+			if envTag.Default != "" {
+				if err := field.set(defaultValue, envTag.Default); err != nil {
+					return nil, err
+				}
+			}
+			formattedDefault, err := format(defaultValue, envTag)
+			if err != nil {
+				return nil, err
+			}
+			if value == formattedDefault {
+				continue
+			}
 		}
 
-		for _, envKeyNames := range envTag.Keys {
-			flagName = toFlagName(envKeyNames)
-			if flags.Lookup(flagName) == nil {
-				flags.String(flagName, envTag.Default, description)
+		args = append(args, fmt.Sprintf("-%s=%s", flagName, value))
+	}
+
+	for _, sv := range p.ifaceStructs(rv) {
+		if args, err = appendStructArgs(args, reads, sv, o); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// checkFlagReads returns an error if a field of reads would not get its own
+// value back from args, picking the flag Resolver.Lookup picks: the custom
+// flag, or else the first of its key flags that is set.
+func checkFlagReads(args []string, reads []flagRead) error {
+	emitted := make(map[string]string, len(args))
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg[1:], "=")
+		emitted[name] = value
+	}
+
+	for _, r := range reads {
+		names := r.field.KeyFlags
+		if r.field.Tag.Flag != "" {
+			names = append([]string{r.field.Tag.Flag}, names...)
+		}
+		for _, name := range names {
+			value, ok := emitted[name]
+			if !ok {
+				continue
 			}
+			if !r.value.IsValid() {
+				return fmt.Errorf("%s is not set but would read -%s=%s; give it a flag of its own", r.field.Path, name, value)
+			}
+			own, err := format(r.value, r.field.Tag)
+			if err != nil {
+				return err
+			}
+			if own != value {
+				return fmt.Errorf("%s would read -%s=%s instead of its value %q; give it a flag of its own", r.field.Path, name, value, own)
+			}
+			break
 		}
 	}
 	return nil
 }
 
-func generateDescription(t tag) string {
+func generateDescription(t tag, choices []string) string {
 	var parts []string
 
 	if t.Desc != "" {
@@ -94,6 +267,10 @@ func generateDescription(t tag) string {
 		parts = append(parts, fmt.Sprintf("Environment: %s", strings.Join(t.Keys, ", ")))
 	}
 
+	if len(choices) > 0 {
+		parts = append(parts, fmt.Sprintf("Choices: %s", strings.Join(choices, ", ")))
+	}
+
 	if t.Default != "" {
 		parts = append(parts, fmt.Sprintf("Default: %s", t.Default))
 	}
@@ -132,27 +309,24 @@ func filterUndefinedAndDups(flags *flag.FlagSet, args []string) []string {
 	for i := 0; i < len(args); {
 		arg := args[i]
 
-		if len(arg) == 0 || arg[0] != '-' {
+		// like the flag package, stop at the -- terminator and take a lone
+		// - as a positional argument
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
 			i++
 			continue
 		}
 
-		splitArg := strings.SplitN(arg, "=", 2)
-
-		var flagName string
-		if strings.HasPrefix(splitArg[0], "--") {
-			flagName = splitArg[0][2:]
-		} else {
-			flagName = splitArg[0][1:]
-		}
-
-		exists := flags.Lookup(flagName) != nil || flagName == "help" || flagName == "h"
+		flagName, hasValue := splitFlagArg(arg)
+		exists := isFlagDefined(flags, flagName)
 		var nextArg string
 		if i < len(args)-1 {
 			nextArg = args[i+1]
 		}
 
-		if len(splitArg) == 1 {
+		if !hasValue {
 			if ok := seen[flagName]; exists && !ok {
 				seen[flagName] = true
 				filteredArgs = append(filteredArgs, arg, nextArg)
@@ -169,12 +343,53 @@ func filterUndefinedAndDups(flags *flag.FlagSet, args []string) []string {
 	return filteredArgs
 }
 
-func isFlagSet(flags *flag.FlagSet, name string) bool {
-	fSet := false
-	flags.Visit(func(f *flag.Flag) {
-		if f.Name == name {
-			fSet = true
+// undefinedFlags returns the names of the flags in args that are not defined
+// in flags, walking args the same way filterUndefinedAndDups does.
+func undefinedFlags(flags *flag.FlagSet, args []string) []string {
+	var undefined []string
+	for i := 0; i < len(args); {
+		arg := args[i]
+
+		// like the flag package, stop at the -- terminator and take a lone
+		// - as a positional argument
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			i++
+			continue
+		}
+
+		flagName, hasValue := splitFlagArg(arg)
+		if !isFlagDefined(flags, flagName) {
+			undefined = append(undefined, flagName)
 		}
-	})
-	return fSet
+
+		if hasValue {
+			i++
+		} else {
+			i += 2
+		}
+	}
+	return undefined
+}
+
+// splitFlagArg returns the flag name of a -flag, --flag, -flag=value or
+// --flag=value argument and whether the value is part of the argument.
+func splitFlagArg(arg string) (string, bool) {
+	splitArg := strings.SplitN(arg, "=", 2)
+
+	var flagName string
+	if strings.HasPrefix(splitArg[0], "--") {
+		flagName = splitArg[0][2:]
+	} else {
+		flagName = splitArg[0][1:]
+	}
+	return flagName, len(splitArg) == 2
+}
+
+// isFlagDefined reports whether name is defined in flags or is one of the
+// help flags handled by the flag package.
+func isFlagDefined(flags *flag.FlagSet, name string) bool {
+	return flags.Lookup(name) != nil || name == "help" || name == "h"
 }
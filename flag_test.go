@@ -15,6 +15,7 @@
 package env
 
 import (
+	"flag"
 	"reflect"
 	"testing"
 	"time"
@@ -124,6 +125,21 @@ func TestFlagPriority(t *testing.T) {
 	}
 }
 
+func TestFilterUndefinedAndDupsTerminator(t *testing.T) {
+	t.Parallel()
+	var validStruct ValidStruct
+	flags, err := RegisterFlags(&validStruct)
+	if err != nil {
+		t.Errorf("Expected no error while register but got '%s'", err)
+	}
+
+	args := []string{"-", "-home=/home/test", "--", "-home=/home/bad"}
+	expected := []string{"-home=/home/test"}
+	if filteredArgs := filterUndefinedAndDups(flags, args); !reflect.DeepEqual(filteredArgs, expected) {
+		t.Errorf("Expected filtered args to be '%v' but got '%v'", expected, filteredArgs)
+	}
+}
+
 func TestFlagUnmarshalPointer(t *testing.T) {
 	t.Parallel()
 	var (
@@ -326,4 +342,154 @@ func TestFlagUnmarshalRequiredValues(t *testing.T) {
 	}
 }
 
-// TODO: do we need marshal for flags?
+func TestMarshalFlags(t *testing.T) {
+	t.Parallel()
+	var (
+		pointerInt  = 2
+		validStruct = ValidStruct{
+			Home:           "/home/test",
+			PointerInt:     &pointerInt,
+			Int:            1,
+			MultipleTags:   "foobar",
+			TagWithDefault: "default_tag_value",
+			Duration:       3 * time.Minute,
+		}
+	)
+	validStruct.Jenkins.Workspace = "/mnt/builds/slave/workspace/test"
+
+	args, err := MarshalFlags(&validStruct, OmitDefaults())
+	if err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+
+	expected := []string{
+		"-home=/home/test",
+		"-workspace=/mnt/builds/slave/workspace/test",
+		"-pointer-int=2",
+		"-int=1",
+		"-npm-config-cache=foobar",
+		"-multiple-tags-with-default=",
+		"-type-duration=3m0s",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected args to be '%v' but got '%v'", expected, args)
+	}
+
+	args, err = MarshalFlags(&validStruct)
+	if err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+	if len(args) != 15 {
+		t.Errorf("Expected %d args but got %d: '%v'", 15, len(args), args)
+	}
+}
+
+func TestMarshalFlagsOmitExpandedDefaults(t *testing.T) {
+	t.Parallel()
+	type ExpandStruct struct {
+		Port  int    `env:"PORT,default=${DEFAULT_PORT:-80}"`
+		Cache string `env:"CACHE,default=${HOME}/.cache"`
+		Raw   string `env:"RAW,expand=false,default=$HOME"`
+	}
+	in := ExpandStruct{Port: 80, Cache: "${HOME}/.cache", Raw: "$HOME"}
+	args, err := MarshalFlags(&in, OmitDefaults())
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := []string{"-port=80", "-cache=${HOME}/.cache"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected args to be '%v' but got '%v'", expected, args)
+	}
+}
+
+func TestMarshalFlagsRoundTrip(t *testing.T) {
+	t.Parallel()
+	var (
+		in    DefaultValueStruct
+		out   DefaultValueStruct
+		flags = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	)
+
+	// start from the defaults and change a couple of fields
+	if err := Unmarshal(flags, map[string]string{}, &in); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+	in.DefaultString = "changed"
+	in.DefaultStringSlice = []string{"other", "values"}
+
+	args, err := MarshalFlags(&in, OmitDefaults())
+	if err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+
+	expected := []string{"-missing-string=changed", "-missing-string-slice=other|values"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected args to be '%v' but got '%v'", expected, args)
+	}
+
+	flags, err = RegisterFlags(&out)
+	if err != nil {
+		t.Errorf("Expected no error while register but got '%s'", err)
+	}
+	if err := flags.Parse(args); err != nil {
+		t.Errorf("Expected flag set to parse args but got '%s'", err)
+	}
+	if err := Unmarshal(flags, map[string]string{}, &out); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+
+	testCases := [][]interface{}{
+		{out.DefaultString, "changed"},
+		{out.DefaultInt, 7},
+		{out.DefaultStringSlice, []string{"other", "values"}},
+		{out.DefaultBool, true},
+	}
+	for _, testCase := range testCases {
+		if !reflect.DeepEqual(testCase[0], testCase[1]) {
+			t.Errorf("Expected field value to be '%v' but got '%v'", testCase[1], testCase[0])
+		}
+	}
+}
+
+func TestMarshalFlagsSharedKey(t *testing.T) {
+	t.Parallel()
+	type SharedKeyStruct struct {
+		Listen string `env:"ADDR"`
+		Public string `env:"ADDR,PUBLIC_ADDR"`
+		Bind   string `env:"ADDR"`
+	}
+
+	// Public and Bind read -addr, which belongs to Listen
+	for _, in := range []SharedKeyStruct{{Listen: "a", Public: "b", Bind: "a"}, {Listen: "a", Public: "a", Bind: "c"}} {
+		if _, err := MarshalFlags(&in); err == nil {
+			t.Errorf("%+v: Expected an error for a value read from -addr", in)
+		}
+	}
+	if _, err := MarshalFlags(&SharedKeyStruct{Public: "b"}, OmitDefaults()); err != nil {
+		t.Errorf("Expected no error without -addr but got '%s'", err)
+	}
+
+	in := SharedKeyStruct{Listen: "a", Public: "a", Bind: "a"}
+	args, err := MarshalFlags(&in)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if expected := []string{"-addr=a", "-public-addr=a"}; !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected args to be '%v' but got '%v'", expected, args)
+	}
+
+	var out SharedKeyStruct
+	flags, err := RegisterFlags(&out)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("Expected flag set to parse args but got '%s'", err)
+	}
+	if err := Unmarshal(flags, EnvSet{}, &out); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if out != in {
+		t.Errorf("Expected '%+v' to round trip but got '%+v'", in, out)
+	}
+}
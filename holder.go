@@ -0,0 +1,124 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Holder holds the current value of a config struct of type T for
+// concurrent readers. Updates unmarshal into a fresh value and swap it in
+// atomically, so readers see either the old or the new config, never a mix.
+// Values returned by Get must be treated as read only.
+//
+// The zero Holder is ready to use and holds the zero T until the first
+// successful Update. A Holder can be passed to NewWatcher in place of a
+// pointer to a struct.
+//
+// Updates only set the env tagged fields of T and copy the others from the
+// current config. As a Holder starts from the zero T, those other fields
+// stay zero: T is meant to hold env tagged fields only, with clients and
+// other state set up in code kept outside of it. Interface fields would stay
+// nil too, so Update and NewWatcher reject a T that has some.
+type Holder[T any] struct {
+	current atomic.Pointer[T]
+	// mu serialises updates and guards subscribers
+	mu          sync.Mutex
+	subscribers []func(old, new T)
+}
+
+// Get returns the current config.
+func (h *Holder[T]) Get() T {
+	if p := h.current.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Subscribe registers fn to be called with the previous and the new config
+// after every successful update. Subscribers run one update at a time and
+// must not update h.
+func (h *Holder[T]) Subscribe(fn func(old, new T)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers = append(h.subscribers, fn)
+}
+
+// Update unmarshals flags and es into a new T, as Unmarshal does with the
+// AllErrors option, and makes it the current config. If any field fails the
+// current config is kept and the joined errors are returned.
+func (h *Holder[T]) Update(flags *flag.FlagSet, es EnvSet, opts ...Option) error {
+	if err := h.check(); err != nil {
+		return err
+	}
+	next := reflect.ValueOf(new(T))
+	if next.Elem().Kind() == reflect.Struct {
+		var err error
+		if next, err = reloadCopy(h.value()); err != nil {
+			return err
+		}
+	}
+	if err := Unmarshal(flags, es, next.Interface(), append(opts, AllErrors())...); err != nil {
+		return err
+	}
+	h.swap(next)
+	return nil
+}
+
+// value implements reloadTarget.
+func (h *Holder[T]) value() reflect.Value {
+	p := h.current.Load()
+	if p == nil {
+		p = new(T)
+	}
+	return reflect.ValueOf(p)
+}
+
+// swap implements reloadTarget.
+func (h *Holder[T]) swap(next reflect.Value) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p := next.Interface().(*T)
+	old := h.current.Swap(p)
+	if old == nil {
+		old = new(T)
+	}
+	for _, fn := range h.subscribers {
+		fn(*old, *p)
+	}
+}
+
+// check implements reloadTarget. The interface fields of T are always nil in
+// a Holder, so the structs they are meant to point to would never be read.
+func (h *Holder[T]) check() error {
+	t := reflect.TypeOf(new(T)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	p, err := planOf(t)
+	if err != nil {
+		return err
+	}
+	if len(p.ifaces) > 0 {
+		return fmt.Errorf("interface field %s of %s is always nil in a Holder; pass a pointer to the struct to NewWatcher instead", p.ifaces[0].Path, t)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type HolderStruct struct {
+	Token string `env:"TOKEN,required=true"`
+	Limit int    `env:"LIMIT,default=10"`
+	Burst int    `env:"BURST,default=20"`
+}
+
+func TestHolderUpdate(t *testing.T) {
+	t.Parallel()
+	var (
+		h     Holder[HolderStruct]
+		flags = flag.NewFlagSet("test", flag.ContinueOnError)
+		calls [][2]HolderStruct
+	)
+	if got := h.Get(); got != (HolderStruct{}) {
+		t.Errorf("Expected the zero config but got '%+v'", got)
+	}
+	h.Subscribe(func(old, new HolderStruct) {
+		calls = append(calls, [2]HolderStruct{old, new})
+	})
+
+	if err := h.Update(flags, EnvSet{"TOKEN": "first"}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := h.Update(flags, EnvSet{"TOKEN": "second", "LIMIT": "5"}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	first := HolderStruct{Token: "first", Limit: 10, Burst: 20}
+	second := HolderStruct{Token: "second", Limit: 5, Burst: 20}
+	if got := h.Get(); got != second {
+		t.Errorf("Expected config to be '%+v' but got '%+v'", second, got)
+	}
+	expected := [][2]HolderStruct{{{}, first}, {first, second}}
+	if len(calls) != len(expected) || calls[0] != expected[0] || calls[1] != expected[1] {
+		t.Errorf("Expected subscriber calls '%+v' but got '%+v'", expected, calls)
+	}
+}
+
+func TestHolderInterface(t *testing.T) {
+	t.Parallel()
+	type IfaceHolderStruct struct {
+		Token  string `env:"TOKEN"`
+		Plugin interface{}
+	}
+	var h Holder[IfaceHolderStruct]
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := h.Update(flags, EnvSet{"TOKEN": "t"}); err == nil || !strings.Contains(err.Error(), "interface field Plugin") {
+		t.Errorf("Expected an error for the interface field but got '%v'", err)
+	}
+	if _, err := NewWatcher(&h, flags, &envSource{es: EnvSet{}}); err == nil {
+		t.Errorf("Expected NewWatcher to reject the Holder")
+	}
+}
+
+func TestHolderUpdateError(t *testing.T) {
+	t.Parallel()
+	var (
+		h      Holder[HolderStruct]
+		flags  = flag.NewFlagSet("test", flag.ContinueOnError)
+		called bool
+	)
+	if err := h.Update(flags, EnvSet{"TOKEN": "first"}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	h.Subscribe(func(old, new HolderStruct) {
+		called = true
+	})
+
+	err := h.Update(flags, EnvSet{"LIMIT": "many", "BURST": "more"})
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	for _, key := range []string{"TOKEN", "LIMIT", "BURST"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error to name %s but got '%s'", key, err)
+		}
+	}
+	if got := h.Get(); got.Token != "first" || called {
+		t.Errorf("Expected the previous config to be kept but got '%+v'", got)
+	}
+}
+
+func TestHolderConcurrent(t *testing.T) {
+	t.Parallel()
+	var (
+		h     Holder[HolderStruct]
+		flags = flag.NewFlagSet("test", flag.ContinueOnError)
+		wg    sync.WaitGroup
+	)
+	if err := h.Update(flags, EnvSet{"TOKEN": "0", "LIMIT": "0", "BURST": "0"}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				// every field of a config is written from the same number
+				cfg := h.Get()
+				if cfg.Token != strconv.Itoa(cfg.Limit) || cfg.Limit != cfg.Burst {
+					t.Errorf("Expected a consistent config but got '%+v'", cfg)
+					return
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 100; i++ {
+		n := strconv.Itoa(i)
+		if err := h.Update(flags, EnvSet{"TOKEN": n, "LIMIT": n, "BURST": n}); err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestHolderWatcher(t *testing.T) {
+	t.Parallel()
+	var h Holder[HolderStruct]
+	source := &envSource{es: EnvSet{"TOKEN": "first"}}
+	w, err := NewWatcher(&h, flag.NewFlagSet("test", flag.ContinueOnError), source)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if got := h.Get().Token; got != "first" {
+		t.Errorf("Expected TOKEN to be 'first' but got '%s'", got)
+	}
+
+	old := h.Get()
+	source.set(EnvSet{"TOKEN": "second"}, nil)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if got := h.Get().Token; got != "second" || old.Token != "first" {
+		t.Errorf("Expected TOKEN to be swapped to 'second' but got '%s'", got)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gorun runs a throwaway Go program next to a user package. It lets
+// the go generate tools in cmd/ hand a type they only know by name to the
+// reflection based generators of package env.
+package gorun
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrMainPackage returned when the target directory holds a main package,
+// which cannot be imported by the generated program.
+var ErrMainPackage = errors.New("types in package main cannot be imported; move them to a library package")
+
+// Package describes the Go package found in a directory.
+type Package struct {
+	// Dir is the directory holding the package sources
+	Dir string
+	// ImportPath is the path used to import the package
+	ImportPath string
+	// Name is the package name used in its package clause
+	Name string
+}
+
+// Load describes the package in dir using the go tool.
+func Load(dir string) (Package, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}} {{.Name}}", ".")
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return Package{}, fmt.Errorf("go list: %w", err)
+	}
+
+	parts := strings.Fields(string(out))
+	if len(parts) != 2 {
+		return Package{}, fmt.Errorf("go list: unexpected output %q", out)
+	}
+	if parts[1] == "main" {
+		return Package{}, ErrMainPackage
+	}
+	return Package{Dir: dir, ImportPath: parts[0], Name: parts[1]}, nil
+}
+
+// Run writes src as the only file of a temporary main package inside the
+// package directory, so it resolves imports through the same module, and
+// runs it. The program's standard output is copied to stdout. The files of
+// hide are left out of the build without being touched on disk.
+func Run(pkg Package, src []byte, stdout io.Writer, hide ...string) error {
+	// the leading underscore keeps the directory out of ./... patterns
+	// should a run be interrupted before the cleanup
+	tmp, err := os.MkdirTemp(pkg.Dir, "_gorun")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), src, 0o644); err != nil {
+		return err
+	}
+
+	args := []string{"run"}
+	if len(hide) > 0 {
+		// an empty replacement makes the go tool treat the file as deleted
+		overlay := struct{ Replace map[string]string }{map[string]string{}}
+		for _, path := range hide {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			overlay.Replace[abs] = ""
+		}
+		data, err := json.Marshal(overlay)
+		if err != nil {
+			return err
+		}
+		overlayPath := filepath.Join(tmp, "overlay.json")
+		if err := os.WriteFile(overlayPath, data, 0o644); err != nil {
+			return err
+		}
+		args = append(args, "-overlay", overlayPath)
+	}
+	args = append(args, "./"+filepath.Base(tmp))
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("go", args...)
+	cmd.Dir = pkg.Dir
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go run: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// JoinValues joins the formatted elements of a slice or array with
+// separator, the inverse of the split done by Unmarshal. It returns an error
+// rather than a value Unmarshal would read differently: when an element
+// contains separator, or when the only element is empty, which reads back
+// as no elements.
+//
+// JoinValues is used by Marshal and the code generated by cmd/envgen.
+func JoinValues(values []string, separator string) (string, error) {
+	if len(values) == 1 && values[0] == "" {
+		return "", errors.New("a single empty element cannot be told apart from no elements")
+	}
+	for i, v := range values {
+		if strings.Contains(v, separator) {
+			return "", fmt.Errorf("element %d %q contains the separator %q", i, v, separator)
+		}
+	}
+	return strings.Join(values, separator), nil
+}
+
+// JoinEntries joins the formatted keys and values of a map as key=value
+// entries separated by separator, sorted for a stable output. It returns an
+// error rather than a value Unmarshal would read differently: when a key
+// contains = or separator, or when a value contains separator.
+//
+// JoinEntries is used by Marshal and the code generated by cmd/envgen.
+func JoinEntries(keys, values []string, separator string) (string, error) {
+	entries := make([]string, len(keys))
+	for i, k := range keys {
+		if strings.Contains(k, "=") || strings.Contains(k, separator) {
+			return "", fmt.Errorf("map key %q contains = or the separator %q", k, separator)
+		}
+		if strings.Contains(values[i], separator) {
+			return "", fmt.Errorf("value %q of map key %q contains the separator %q", values[i], k, separator)
+		}
+		entries[i] = k + "=" + values[i]
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, separator), nil
+}
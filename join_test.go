@@ -0,0 +1,65 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJoinValues(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		values   []string
+		expected string
+		reason   string
+	}{
+		{nil, "", ""},
+		{[]string{"a", "b"}, "a|b", ""},
+		{[]string{"", ""}, "|", ""},
+		{[]string{"a=b"}, "a=b", ""},
+		{[]string{""}, "", "a single empty element"},
+		{[]string{"a", "b|c"}, "", `element 1 "b|c" contains the separator "|"`},
+	}
+	for _, tt := range tests {
+		got, err := JoinValues(tt.values, "|")
+		if tt.reason != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("%q: Expected error '%s' but got '%v'", tt.values, tt.reason, err)
+			}
+		} else if err != nil || got != tt.expected {
+			t.Errorf("%q: Expected '%s' but got '%s', %v", tt.values, tt.expected, got, err)
+		}
+	}
+}
+
+func TestJoinEntries(t *testing.T) {
+	t.Parallel()
+	got, err := JoinEntries([]string{"b", "a", ""}, []string{"x=1", "", ""}, ";")
+	if expected := "=;a=;b=x=1"; err != nil || got != expected {
+		t.Errorf("Expected '%s' but got '%s', %v", expected, got, err)
+	}
+
+	tests := map[string][2]string{
+		`map key "k=x" contains =`:                  {"k=x", "v"},
+		`map key "a;b" contains = or the separator`: {"a;b", "v"},
+		`value "a;b" of map key "k"`:                {"k", "a;b"},
+	}
+	for reason, entry := range tests {
+		if _, err := JoinEntries([]string{entry[0]}, []string{entry[1]}, ";"); err == nil || !strings.Contains(err.Error(), reason) {
+			t.Errorf("%q: Expected error '%s' but got '%v'", entry, reason, err)
+		}
+	}
+}
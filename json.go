@@ -0,0 +1,348 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JSONOption configures JSONToEnvSet and JSONSource.
+type JSONOption func(*jsonOptions)
+
+type jsonOptions struct {
+	// fieldPaths matches nested objects against Go field paths instead of
+	// matching top level members against env keys
+	fieldPaths bool
+}
+
+// JSONFieldPaths makes JSONToEnvSet match nested JSON objects against the Go
+// field paths of the struct, case-insensitively as encoding/json does, so
+// {"Database": {"Port": 5432}} sets the field Database.Port. By default the
+// JSON document is a flat object keyed by env keys.
+func JSONFieldPaths() JSONOption {
+	return func(o *jsonOptions) {
+		o.fieldPaths = true
+	}
+}
+
+// JSONSource returns a Source reading the JSON file at path with
+// JSONToEnvSet. Chained below the environment,
+//
+//	Chain(EnvironSource(), JSONSource("config.json", &cfg))
+//
+// gives the precedence flags, then env, then file, then defaults.
+func JSONSource(path string, v interface{}, opts ...JSONOption) Source {
+	return SourceFunc(func() (EnvSet, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		es, err := JSONToEnvSet(data, v, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return es, nil
+	})
+}
+
+// JSONToEnvSet turns the JSON object data into an EnvSet for the struct
+// pointed to by v. Each member of the object matching a field of v becomes
+// the value of the first env key of the field, so it goes through the same
+// conversions as environment variables:
+//
+//   - strings are taken as is, numbers and booleans as their JSON text
+//   - arrays of a slice field are joined with the separator of the field,
+//     which no element may contain
+//   - objects of a map field become key=value entries joined likewise, with
+//     no = or separator in member names and no separator in their values
+//   - arrays and objects of a field implementing Unmarshaler are passed as
+//     JSON text
+//   - null leaves the field unset
+//
+// Members matching no field are skipped in both modes, except top level
+// scalars when matching env keys, which are kept as is. Every value is
+// checked by parsing it into its field; errors name the JSON pointer of the
+// offending value.
+//
+// If v is nil or not a pointer to a struct, JSONToEnvSet returns an
+// ErrInvalidValue.
+func JSONToEnvSet(data []byte, v interface{}, opts ...JSONOption) (EnvSet, error) {
+	fields, err := collectFields(v)
+	if err != nil {
+		return nil, err
+	}
+	o := &jsonOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, &ErrJSONValue{Pointer: "", Err: errors.New("expected an object")}
+	}
+
+	es := make(EnvSet)
+	if o.fieldPaths {
+		d := &jsonPathDecoder{es: es, leaves: map[string]field{}, parents: map[string]bool{}}
+		for _, f := range fields {
+			if len(f.Tag.Keys) == 0 {
+				continue
+			}
+			path := strings.ToLower(f.Path)
+			if _, ok := d.leaves[path]; !ok {
+				d.leaves[path] = f
+			}
+			for i := range path {
+				if path[i] == '.' {
+					d.parents[path[:i]] = true
+				}
+			}
+		}
+		return es, d.object(root, "", "")
+	}
+
+	byKey := map[string]field{}
+	for _, f := range fields {
+		for _, envKey := range f.Tag.Keys {
+			if _, ok := byKey[envKey]; !ok {
+				byKey[envKey] = f
+			}
+		}
+	}
+	for _, k := range sortedMembers(root) {
+		pointer := "/" + jsonPointerEscape(k)
+		f, ok := byKey[k]
+		if !ok {
+			if s, ok := jsonScalar(root[k]); ok {
+				es[k] = s
+			}
+			continue
+		}
+		if err := storeJSONValue(es, k, f, root[k], pointer); err != nil {
+			return nil, err
+		}
+	}
+	return es, nil
+}
+
+// ErrJSONValue returned when a value of a JSON document cannot be used for
+// its field.
+type ErrJSONValue struct {
+	// Pointer is the JSON pointer (RFC 6901) of the offending value
+	Pointer string
+	Err     error
+}
+
+func (e ErrJSONValue) Error() string {
+	return fmt.Sprintf("json value at %q: %s", e.Pointer, e.Err)
+}
+
+func (e ErrJSONValue) Unwrap() error {
+	return e.Err
+}
+
+// jsonPathDecoder matches nested JSON objects against Go field paths.
+type jsonPathDecoder struct {
+	es EnvSet
+	// leaves maps lower-cased field paths to their tagged fields
+	leaves map[string]field
+	// parents holds the lower-cased paths of the structs holding fields
+	parents map[string]bool
+}
+
+func (d *jsonPathDecoder) object(obj map[string]interface{}, pointer, path string) error {
+	for _, k := range sortedMembers(obj) {
+		memberPointer := pointer + "/" + jsonPointerEscape(k)
+		memberPath := strings.ToLower(k)
+		if path != "" {
+			memberPath = path + "." + memberPath
+		}
+
+		value := obj[k]
+		nested, isObject := value.(map[string]interface{})
+		f, isLeaf := d.leaves[memberPath]
+		// an object for a struct is walked into, unless the struct reads the
+		// object itself
+		if isObject && d.parents[memberPath] && !(isLeaf && acceptsJSONText(f.Type)) {
+			if err := d.object(nested, memberPointer, memberPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isLeaf {
+			continue
+		}
+		if err := storeJSONValue(d.es, f.Tag.Keys[0], f, value, memberPointer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeJSONValue converts value to the string form of f, checks that f
+// parses it and stores it as key.
+func storeJSONValue(es EnvSet, key string, f field, value interface{}, pointer string) error {
+	if value == nil {
+		return nil
+	}
+	s, err := jsonFieldValue(f, value, pointer)
+	if err != nil {
+		return err
+	}
+	// values referencing variables can only be checked once expanded
+	if f.Tag.NoExpand || !strings.Contains(s, "$") {
+		if err := f.set(reflect.New(f.Type).Elem(), s); err != nil {
+			return &ErrJSONValue{Pointer: pointer, Err: err}
+		}
+	}
+	es[key] = s
+	return nil
+}
+
+// jsonFieldValue returns the string form of value that set parses into f.
+// Errors are ErrJSONValue naming pointer, or the pointer of the offending
+// element or member.
+func jsonFieldValue(f field, value interface{}, pointer string) (string, error) {
+	if s, ok := jsonScalar(value); ok {
+		return s, nil
+	}
+	if acceptsJSONText(f.Type) {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", &ErrJSONValue{Pointer: pointer, Err: err}
+		}
+		return string(b), nil
+	}
+
+	separator := f.Tag.separator()
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch value := value.(type) {
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return "", &ErrJSONValue{Pointer: pointer, Err: fmt.Errorf("cannot use an array for %s", f.Type)}
+		}
+		values := make([]string, len(value))
+		for i, e := range value {
+			elemPointer := fmt.Sprintf("%s/%d", pointer, i)
+			s, ok := jsonScalar(e)
+			if !ok {
+				return "", &ErrJSONValue{Pointer: elemPointer, Err: fmt.Errorf("cannot use %s for %s", jsonKind(e), t.Elem())}
+			}
+			if strings.Contains(s, separator) {
+				return "", &ErrJSONValue{Pointer: elemPointer, Err: fmt.Errorf("%q contains the separator %q", s, separator)}
+			}
+			values[i] = s
+		}
+		joined, err := JoinValues(values, separator)
+		if err != nil {
+			return "", &ErrJSONValue{Pointer: pointer, Err: err}
+		}
+		return joined, nil
+	case map[string]interface{}:
+		if t.Kind() != reflect.Map {
+			return "", &ErrJSONValue{Pointer: pointer, Err: fmt.Errorf("cannot use an object for %s", f.Type)}
+		}
+		keys := sortedMembers(value)
+		values := make([]string, len(keys))
+		for i, k := range keys {
+			memberPointer := pointer + "/" + jsonPointerEscape(k)
+			s, ok := jsonScalar(value[k])
+			if !ok {
+				return "", &ErrJSONValue{Pointer: memberPointer, Err: fmt.Errorf("cannot use %s for %s", jsonKind(value[k]), t.Elem())}
+			}
+			if strings.Contains(k, "=") || strings.Contains(k, separator) {
+				return "", &ErrJSONValue{Pointer: memberPointer, Err: fmt.Errorf("member name %q contains = or the separator %q", k, separator)}
+			}
+			if strings.Contains(s, separator) {
+				return "", &ErrJSONValue{Pointer: memberPointer, Err: fmt.Errorf("%q contains the separator %q", s, separator)}
+			}
+			values[i] = s
+		}
+		joined, err := JoinEntries(keys, values, separator)
+		if err != nil {
+			return "", &ErrJSONValue{Pointer: pointer, Err: err}
+		}
+		return joined, nil
+	}
+	return "", &ErrJSONValue{Pointer: pointer, Err: fmt.Errorf("cannot use %s for %s", jsonKind(value), f.Type)}
+}
+
+// jsonScalar returns the text of a JSON string, number or boolean.
+func jsonScalar(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		if value {
+			return "true", true
+		}
+		return "false", true
+	}
+	return "", false
+}
+
+// jsonKind names the JSON type of value for error messages.
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case nil:
+		return "null"
+	}
+	return "a scalar"
+}
+
+// acceptsJSONText reports whether t implements Unmarshaler, in which case
+// arrays and objects are passed to it as JSON text.
+func acceptsJSONText(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return reflect.PointerTo(t).Implements(unmarshalType)
+}
+
+func sortedMembers(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonPointerEscape escapes a member name for use as a JSON pointer token.
+func jsonPointerEscape(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,169 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type JSONStruct struct {
+	Name     string            `env:"APP_NAME,default=app"`
+	Debug    bool              `env:"DEBUG"`
+	Timeout  time.Duration     `env:"TIMEOUT"`
+	Hosts    []string          `env:"HOSTS,separator=;"`
+	Limits   map[string]int    `env:"LIMITS"`
+	Data     JSONData          `env:"DATA"`
+	Optional *int              `env:"OPTIONAL"`
+	Labels   map[string]string `env:"LABELS"`
+	Database struct {
+		Host string `env:"DB_HOST"`
+		Port uint16 `env:"DB_PORT,default=5432"`
+	}
+}
+
+func TestJSONToEnvSet(t *testing.T) {
+	t.Parallel()
+	data := `{
+		"DEBUG": true,
+		"TIMEOUT": "5s",
+		"HOSTS": ["a", "b"],
+		"LIMITS": {"mem": 512, "cpu": 2},
+		"DATA": {"name": "test"},
+		"OPTIONAL": null,
+		"DB_PORT": 6543,
+		"OTHER": 1.5,
+		"NESTED": {"x": [1]},
+		"LIST": []
+	}`
+	es, err := JSONToEnvSet([]byte(data), new(JSONStruct))
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := EnvSet{
+		"DEBUG":   "true",
+		"TIMEOUT": "5s",
+		"HOSTS":   "a;b",
+		"LIMITS":  "cpu=2|mem=512",
+		"DATA":    `{"name":"test"}`,
+		"DB_PORT": "6543",
+		"OTHER":   "1.5",
+	}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected EnvSet to be '%v' but got '%v'", expected, es)
+	}
+}
+
+func TestJSONToEnvSetFieldPaths(t *testing.T) {
+	t.Parallel()
+	data := `{
+		"name": "billing",
+		"Hosts": ["a"],
+		"database": {"host": "db.internal", "Port": 6543, "unknown": true},
+		"Unknown": {"x": 1}
+	}`
+	es, err := JSONToEnvSet([]byte(data), new(JSONStruct), JSONFieldPaths())
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := EnvSet{
+		"APP_NAME": "billing",
+		"HOSTS":    "a",
+		"DB_HOST":  "db.internal",
+		"DB_PORT":  "6543",
+	}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected EnvSet to be '%v' but got '%v'", expected, es)
+	}
+}
+
+func TestJSONToEnvSetErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		data    string
+		opts    []JSONOption
+		pointer string
+	}{
+		{`[]`, nil, ""},
+		{`{"DB_PORT": 70000}`, nil, "/DB_PORT"},
+		{`{"DB_PORT": "x"}`, nil, "/DB_PORT"},
+		{`{"DEBUG": [true]}`, nil, "/DEBUG"},
+		{`{"HOSTS": [["a"]]}`, nil, "/HOSTS/0"},
+		{`{"HOSTS": ["a", "b;c"]}`, nil, "/HOSTS/1"},
+		{`{"HOSTS": [""]}`, nil, "/HOSTS"},
+		{`{"LIMITS": {"a": 1, "b|c": 2}}`, nil, "/LIMITS/b|c"},
+		{`{"LABELS": {"a=b": "c"}}`, nil, "/LABELS/a=b"},
+		{`{"LABELS": {"a": "b|c"}}`, nil, "/LABELS/a"},
+		{`{"Hosts": ["a;b"]}`, []JSONOption{JSONFieldPaths()}, "/Hosts/0"},
+		{`{"Database": {"Port": -1}}`, []JSONOption{JSONFieldPaths()}, "/Database/Port"},
+		{`{"Labels": {"a/b": {}}}`, []JSONOption{JSONFieldPaths()}, "/Labels/a~1b"},
+	}
+
+	for _, tt := range tests {
+		_, err := JSONToEnvSet([]byte(tt.data), new(JSONStruct), tt.opts...)
+		var errJSON *ErrJSONValue
+		if !errors.As(err, &errJSON) || errJSON.Pointer != tt.pointer {
+			t.Errorf("%s: Expected an error at %q but got '%v'", tt.data, tt.pointer, err)
+		}
+	}
+
+	if _, err := JSONToEnvSet([]byte(`{`), new(JSONStruct)); err == nil {
+		t.Errorf("Expected a syntax error")
+	}
+	if _, err := JSONToEnvSet([]byte(`{}`), JSONStruct{}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected error 'ErrInvalidValue' but got '%v'", err)
+	}
+}
+
+func TestJSONSourcePrecedence(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"Name": "file", "Debug": true, "Database": {"Host": "file", "Port": 1}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg JSONStruct
+	flags, err := RegisterFlags(&cfg)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	flags.Init("test", flag.ContinueOnError)
+	if err := flags.Parse([]string{"-db-host", "flag"}); err != nil {
+		t.Fatal(err)
+	}
+
+	environ := SourceFunc(func() (EnvSet, error) {
+		return EnvSet{"DB_HOST": "env", "DB_PORT": "2"}, nil
+	})
+	es, err := Chain(environ, JSONSource(path, &cfg, JSONFieldPaths())).Load()
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := Unmarshal(flags, es, &cfg); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if cfg.Database.Host != "flag" || cfg.Database.Port != 2 || cfg.Name != "file" || !cfg.Debug || cfg.Timeout != 0 {
+		t.Errorf("Expected flags, env, file and defaults in that order but got '%+v'", cfg)
+	}
+}
@@ -4,3 +4,10 @@ package env
 type Marshaler interface {
 	MarshalEnvironmentValue() (string, error)
 }
+
+// EnvMarshaler is implemented by config structs with generated marshalling
+// code, see cmd/envgen. Marshal calls MarshalEnv instead of walking the
+// struct with reflection.
+type EnvMarshaler interface {
+	MarshalEnv() (EnvSet, error)
+}
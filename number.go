@@ -0,0 +1,80 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseInt parses value as a signed integer that fits in bitSize bits. Like
+// Go integer literals, value may have a 0x, 0o or 0b base prefix and
+// underscores between digits, so 0x1F, 0o755 and 1_000 are accepted. Without
+// a prefix the number is decimal even with leading zeros: 0755 is 755.
+//
+// ParseInt is used by Unmarshal and the code generated by cmd/envgen.
+func ParseInt(value string, bitSize int) (int64, error) {
+	v, err := strconv.ParseInt(decimalLiteral(value), 0, bitSize)
+	if err != nil {
+		return 0, numberError(value, err, strconv.FormatInt(-1<<(bitSize-1), 10), strconv.FormatInt(1<<(bitSize-1)-1, 10))
+	}
+	return v, nil
+}
+
+// ParseUint is like ParseInt for unsigned integers.
+func ParseUint(value string, bitSize int) (uint64, error) {
+	v, err := strconv.ParseUint(decimalLiteral(value), 0, bitSize)
+	if err != nil {
+		return 0, numberError(value, err, "0", strconv.FormatUint(1<<bitSize-1, 10))
+	}
+	return v, nil
+}
+
+// decimalLiteral strips the leading zeros of value unless it has a base
+// prefix, so strconv parses it as decimal rather than octal.
+func decimalLiteral(value string) string {
+	sign, digits := "", value
+	if digits != "" && (digits[0] == '+' || digits[0] == '-') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if len(digits) < 2 || digits[0] != '0' {
+		return value
+	}
+	switch digits[1] {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return value
+	}
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		digits = "0"
+	}
+	return sign + digits
+}
+
+// numberError reports err for value, naming the bounds [min, max] when value
+// is out of range.
+func numberError(value string, err error, min, max string) error {
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		return err
+	}
+	if numErr.Err == strconv.ErrRange {
+		return fmt.Errorf("%q %w, must be within [%s, %s]", value, strconv.ErrRange, min, max)
+	}
+	numErr.Num = value
+	return numErr
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"flag"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseInt(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value    string
+		bitSize  int
+		expected int64
+	}{
+		{"42", 64, 42},
+		{"-128", 8, -128},
+		{"0x1F", 64, 31},
+		{"0o755", 64, 493},
+		{"-0b101", 64, -5},
+		{"1_000", 64, 1000},
+		{"0755", 64, 755},
+		{"-007", 8, -7},
+		{"000", 8, 0},
+		{"9223372036854775807", 64, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		got, err := ParseInt(tt.value, tt.bitSize)
+		if err != nil {
+			t.Errorf("%s: Expected no error but got '%s'", tt.value, err)
+		} else if got != tt.expected {
+			t.Errorf("%s: Expected %d but got %d", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestParseUint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value    string
+		bitSize  int
+		expected uint64
+	}{
+		{"65535", 16, math.MaxUint16},
+		{"0XFF", 8, 255},
+		{"0o022", 32, 18},
+		{"0022", 32, 22},
+		{"18446744073709551615", 64, math.MaxUint64},
+	}
+	for _, tt := range tests {
+		got, err := ParseUint(tt.value, tt.bitSize)
+		if err != nil {
+			t.Errorf("%s: Expected no error but got '%s'", tt.value, err)
+		} else if got != tt.expected {
+			t.Errorf("%s: Expected %d but got %d", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestParseIntInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value   string
+		bitSize int
+		reason  string
+	}{
+		{"300", 8, `"300" value out of range, must be within [-128, 127]`},
+		{"-129", 8, "must be within [-128, 127]"},
+		{"0x80", 8, `"0x80" value out of range`},
+		{"1__000", 64, `parsing "1__000": invalid syntax`},
+		{"0_755", 64, `parsing "0_755": invalid syntax`},
+		{"1.5", 64, "invalid syntax"},
+		{"", 64, "invalid syntax"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseInt(tt.value, tt.bitSize); err == nil || !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("%s: Expected error '%s' but got '%v'", tt.value, tt.reason, err)
+		}
+	}
+
+	_, err := ParseUint("70000", 16)
+	if !errors.Is(err, strconv.ErrRange) || !strings.Contains(err.Error(), "must be within [0, 65535]") {
+		t.Errorf("Expected a range error within [0, 65535] but got '%v'", err)
+	}
+	if _, err := ParseUint("-1", 64); err == nil {
+		t.Errorf("Expected an error for a negative unsigned integer")
+	}
+}
+
+func TestUnmarshalIntegerBits(t *testing.T) {
+	t.Parallel()
+	type IntegerStruct struct {
+		Small int8   `env:"SMALL"`
+		Port  uint16 `env:"PORT"`
+		Umask uint32 `env:"UMASK"`
+		Mask  int    `env:"MASK"`
+	}
+
+	var integerStruct IntegerStruct
+	es := EnvSet{"SMALL": "-0x10", "PORT": "8_080", "UMASK": "0o022", "MASK": "0b1010"}
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError), es, &integerStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := IntegerStruct{Small: -16, Port: 8080, Umask: 0o022, Mask: 10}
+	if integerStruct != expected {
+		t.Errorf("Expected %+v but got %+v", expected, integerStruct)
+	}
+
+	for key, value := range map[string]string{"SMALL": "300", "PORT": "70000"} {
+		es := EnvSet{key: value}
+		err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError), es, &integerStruct)
+		if !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("%s=%s: Expected a range error but got '%v'", key, value, err)
+		}
+	}
+}
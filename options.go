@@ -0,0 +1,115 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+// Option configures Unmarshal and UnmarshalFromEnviron.
+type Option func(*options)
+
+// consumeMode selects which EnvSet keys Unmarshal deletes.
+type consumeMode int
+
+const (
+	// consumeSource deletes the key that supplied the value of a field
+	consumeSource consumeMode = iota
+	// consumeAllKeys deletes every key of a field that was set
+	consumeAllKeys
+	// consumeNone leaves the EnvSet untouched
+	consumeNone
+)
+
+type options struct {
+	// consume selects which EnvSet keys are deleted once matched
+	consume consumeMode
+	// strict rejects unknown flags
+	strict bool
+	// strictPrefixes are the EnvSet key prefixes owned by the config struct
+	strictPrefixes []string
+	// allErrors keeps going after a field fails and joins the errors
+	allErrors bool
+	// foldCase matches env keys regardless of case
+	foldCase bool
+	// ambiguousKey is called when several keys match an env key
+	ambiguousKey func(key string, matches []string)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Strict makes UnmarshalFromEnviron fail on command line flags that are not
+// defined by the config struct, instead of silently dropping them. With
+// prefixes, Unmarshal also fails when the EnvSet holds a key starting with
+// one of the prefixes that no field reads, such as a misspelled
+// BILLING_PROT next to BILLING_PORT.
+//
+// The returned errors are ErrUnknownFlag and ErrUnknownKey values, joined
+// when there are several, suggesting the closest known name if any.
+func Strict(prefixes ...string) Option {
+	return func(o *options) {
+		o.strict = true
+		o.strictPrefixes = append(o.strictPrefixes, prefixes...)
+	}
+}
+
+// ConsumeAllKeys makes Unmarshal delete every key of a field once the field
+// is set, whether the value came from one of its keys, a flag or the
+// default. Alias keys that lost to the first key present, or to a flag, are
+// then not left behind in the EnvSet.
+func ConsumeAllKeys() Option {
+	return func(o *options) {
+		o.consume = consumeAllKeys
+	}
+}
+
+// PreserveEnvSet makes Unmarshal leave the EnvSet untouched.
+func PreserveEnvSet() Option {
+	return func(o *options) {
+		o.consume = consumeNone
+	}
+}
+
+// AllErrors makes Unmarshal carry on past fields that fail and return all
+// their errors joined with errors.Join, each naming the env key of its
+// field, instead of stopping at the first one. The struct is then only
+// partially set.
+func AllErrors() Option {
+	return func(o *options) {
+		o.allErrors = true
+	}
+}
+
+// CaseInsensitive makes Unmarshal match env keys regardless of case, for
+// tools that pass Path for PATH or lower-case every key. Expansions of
+// ${VAR} references match the same way.
+//
+// When several keys of the EnvSet differ from an env key only by case, the
+// one spelled exactly like the env key wins, and otherwise the one sorting
+// first byte-wise, so upper case wins over lower case. If ambiguous is not
+// nil, it is then called once per env key with the matching keys, the one
+// used first.
+//
+// With Strict, unknown keys are also matched regardless of case. Only the
+// key used is deleted from the EnvSet, or with ConsumeAllKeys, all of its
+// spellings.
+func CaseInsensitive(ambiguous func(key string, matches []string)) Option {
+	return func(o *options) {
+		o.foldCase = true
+		o.ambiguousKey = ambiguous
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// plans caches the structPlan of every struct type seen so far, keyed by its
+// reflect.Type.
+var plans sync.Map
+
+// structPlan is the flattened list of "env" tagged fields of a struct type,
+// with their tags parsed and flag names derived. It is built once per type by
+// planOf and shared read-only between goroutines, so Unmarshal, Marshal and
+// RegisterFlags do not re-walk the struct on every call.
+type structPlan struct {
+	fields []field
+	// ptrs are the pointer to struct fields with tagged fields below them,
+	// outer pointers before the ones below them
+	ptrs []ptrStruct
+	// ifaces are the untagged interface fields, whose value is traversed
+	// when it is a pointer to a struct
+	ifaces []ifaceField
+	// err is the ErrInvalidTag of the first malformed tag, if any
+	err error
+}
+
+// field describes a single "env" tagged struct field of a structPlan.
+type field struct {
+	// Path is the dotted Go field path from the root struct, e.g.
+	// "Jenkins.Workspace"
+	Path string
+	// Index is the index sequence of the field for reflect.Value.FieldByIndex
+	Index []int
+	// Type is the Go type of the field
+	Type reflect.Type
+	// Tag is the parsed "env" field tag
+	Tag tag
+	// Exported is false for unexported fields, which Unmarshal and Marshal
+	// reject with ErrUnexportedField
+	Exported bool
+	// Flags are the flag names registered for the field, custom flag first
+	Flags []string
+	// KeyFlags are the flag names generated from each of the env keys, which
+	// Unmarshal looks up even when another field registered them first
+	KeyFlags []string
+
+	// set parses a value into the field
+	set func(f reflect.Value, value string) error
+}
+
+// ptrStruct is a pointer to struct field of a structPlan, which Unmarshal
+// allocates when it is nil and the fields below it are set.
+type ptrStruct struct {
+	// Path is the dotted Go field path from the root struct
+	Path string
+	// Index is the index sequence of the pointer field
+	Index []int
+	// Type is the struct type pointed to
+	Type reflect.Type
+	// AllocAlways is set by the alloc=always tag option
+	AllocAlways bool
+
+	// first and end delimit the fields below the pointer in
+	// structPlan.fields
+	first, end int
+}
+
+// ifaceField is an interface field of a structPlan. Its dynamic value is
+// only known at run time, so its fields are not part of the plan.
+type ifaceField struct {
+	// Path is the dotted Go field path from the root struct
+	Path string
+	// Index is the index sequence of the interface field
+	Index []int
+}
+
+// planOf returns the cached structPlan of the struct type t, building it on
+// first use. If a field tag of t is malformed, planOf returns an
+// ErrInvalidTag.
+func planOf(t reflect.Type) (*structPlan, error) {
+	if p, ok := plans.Load(t); ok {
+		p := p.(*structPlan)
+		return p, p.err
+	}
+
+	p := &structPlan{}
+	p.addStructFields(t, nil, "", map[string]bool{}, map[reflect.Type]bool{})
+	actual, _ := plans.LoadOrStore(t, p)
+	p = actual.(*structPlan)
+	return p, p.err
+}
+
+// collectFields returns the tagged fields of the struct pointed to by v, in
+// the order Unmarshal and RegisterFlags visit them. If v is nil or not a
+// pointer to a struct, collectFields returns ErrInvalidValue. The returned
+// slice is shared and must not be modified.
+func collectFields(v interface{}) ([]field, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, ErrInvalidValue
+	}
+
+	t := rv.Type().Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, ErrInvalidValue
+	}
+
+	p, err := planOf(t)
+	if err != nil {
+		return nil, err
+	}
+	return p.fields, nil
+}
+
+// addStructFields appends the tagged fields of the struct type t, found at
+// index and path prefix below the root struct, to p.fields. visiting holds
+// the struct types being added, so recursive types are not followed through
+// their pointers forever.
+func (p *structPlan) addStructFields(t reflect.Type, index []int, prefix string, seenFlags map[string]bool, visiting map[reflect.Type]bool) {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := range t.NumField() {
+		typeField := t.Field(i)
+		path := prefix + typeField.Name
+		fieldIndex := append(append(make([]int, 0, len(index)+1), index...), i)
+		fieldType := typeField.Type
+
+		tagString := typeField.Tag.Get("env")
+		var envTag tag
+		if tagString != "" {
+			var err error
+			if envTag, err = parseTag(tagString); err != nil {
+				p.invalidTag(path, tagString, err)
+				continue
+			}
+		}
+
+		isStruct := fieldType.Kind() == reflect.Struct && !isStdType(fieldType)
+		isPtrStruct := isPtrToStruct(fieldType)
+		switch {
+		case (isStruct || isPtrStruct) && !typeField.IsExported():
+			continue
+		case isStruct:
+			p.addStructFields(fieldType, fieldIndex, path+".", seenFlags, visiting)
+		case isPtrStruct && !visiting[fieldType.Elem()]:
+			p.addPtrStruct(fieldType.Elem(), fieldIndex, path, envTag.AllocAlways, seenFlags, visiting)
+		case fieldType.Kind() == reflect.Interface && tagString == "" && typeField.IsExported():
+			p.ifaces = append(p.ifaces, ifaceField{Path: path, Index: fieldIndex})
+		}
+
+		if tagString == "" {
+			continue
+		}
+		if envTag.AllocAlways && !isPtrStruct {
+			p.invalidTag(path, tagString, errors.New("option alloc only applies to pointers to structs"))
+			continue
+		}
+		if len(envTag.Keys) == 0 {
+			// nested structs may be tagged for their options only
+			if !isStruct && !isPtrStruct {
+				p.invalidTag(path, tagString, errors.New("missing env key"))
+			}
+			continue
+		}
+
+		f := field{
+			Path:     path,
+			Index:    fieldIndex,
+			Type:     fieldType,
+			Tag:      envTag,
+			Exported: typeField.IsExported(),
+			set: func(v reflect.Value, value string) error {
+				return set(fieldType, v, value, envTag)
+			},
+		}
+		for _, envKey := range envTag.Keys {
+			f.KeyFlags = append(f.KeyFlags, toFlagName(envKey))
+		}
+
+		if isStruct {
+			// tagged structs are read from the environment by Unmarshal but
+			// RegisterFlags creates no flags for them
+			p.fields = append(p.fields, f)
+			continue
+		}
+
+		// the custom flag is always registered, key derived flags only when
+		// no other field claimed them first
+		if envTag.Flag != "" {
+			seenFlags[envTag.Flag] = true
+			f.Flags = append(f.Flags, envTag.Flag)
+		}
+		for _, flagName := range f.KeyFlags {
+			if !seenFlags[flagName] {
+				seenFlags[flagName] = true
+				f.Flags = append(f.Flags, flagName)
+			}
+		}
+
+		p.fields = append(p.fields, f)
+	}
+}
+
+// addPtrStruct adds the pointer to struct field at index and path, pointing
+// to a struct of type t, and the tagged fields below it. Pointers with
+// nothing to allocate for are left out.
+func (p *structPlan) addPtrStruct(t reflect.Type, index []int, path string, allocAlways bool, seenFlags map[string]bool, visiting map[reflect.Type]bool) {
+	n := len(p.ptrs)
+	p.ptrs = append(p.ptrs, ptrStruct{Path: path, Index: index, Type: t, AllocAlways: allocAlways, first: len(p.fields)})
+	p.addStructFields(t, index, path+".", seenFlags, visiting)
+	p.ptrs[n].end = len(p.fields)
+	if p.ptrs[n].first == p.ptrs[n].end && !allocAlways && len(p.ptrs) == n+1 {
+		p.ptrs = p.ptrs[:n]
+	}
+}
+
+// invalidTag records the ErrInvalidTag of the field at path, unless a
+// previous field already had one.
+func (p *structPlan) invalidTag(path, tagString string, err error) {
+	if p.err == nil {
+		p.err = &ErrInvalidTag{Field: path, Tag: tagString, Err: err}
+	}
+}
+
+// isPtrToStruct reports whether t is a pointer to a nested config struct,
+// rather than to a standard library type set and format handle.
+func isPtrToStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && !isStdType(t) && !isStdType(t.Elem())
+}
+
+// allocPtrs allocates the nil pointer to struct fields of the struct rv
+// that have AllocAlways set or one of whose fields is set according to r.
+// Pointers below a nil pointer that stays nil are left alone.
+func (p *structPlan) allocPtrs(rv reflect.Value, r *Resolver) {
+	for _, ptr := range p.ptrs {
+		pv, err := rv.FieldByIndexErr(ptr.Index)
+		if err != nil || !pv.IsNil() {
+			continue
+		}
+		if ptr.AllocAlways || r.anyPresent(p.fields[ptr.first:ptr.end]) {
+			pv.Set(reflect.New(ptr.Type))
+		}
+	}
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex but allocates the nil
+// pointers to structs on the way to the field.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// ifaceStructs returns the structs pointed to by the interface fields of the
+// struct rv, skipping those that are nil or hold anything but a pointer to
+// a struct.
+func (p *structPlan) ifaceStructs(rv reflect.Value) []reflect.Value {
+	var structs []reflect.Value
+	for _, iface := range p.ifaces {
+		iv, err := rv.FieldByIndexErr(iface.Index)
+		if err != nil || iv.IsNil() {
+			continue
+		}
+		if ev := iv.Elem(); isPtrToStruct(ev.Type()) && !ev.IsNil() {
+			structs = append(structs, ev.Elem())
+		}
+	}
+	return structs
+}
+
+// choices returns the enumerated set of values accepted by the field, or nil
+// if the field accepts arbitrary values.
+func (f field) choices() []string {
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if e := enumOf(t); e != nil {
+		return e.names
+	}
+	if t.Kind() == reflect.Bool {
+		return []string{"true", "false"}
+	}
+	return nil
+}
+
+// enumChoices returns the names accepted by an enum field, or nil if the
+// field is not an enum. Unlike choices it leaves out booleans, whose
+// choices go without saying in help texts and docs.
+func (f field) enumChoices() []string {
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Bool {
+		return nil
+	}
+	return f.choices()
+}
+
+// description returns the flag help text of the field.
+func (f field) description() string {
+	return generateDescription(f.Tag, f.enumChoices())
+}
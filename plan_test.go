@@ -0,0 +1,119 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"flag"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+var benchmarkEnviron = map[string]string{
+	"HOME":             "/home/test",
+	"WORKSPACE":        "/mnt/builds/slave/workspace/test",
+	"INT":              "1",
+	"UINT":             "4294967295",
+	"FLOAT32":          "2.3",
+	"FLOAT64":          "4.5",
+	"BOOL":             "true",
+	"npm_config_cache": "first",
+	"TYPE_DURATION":    "5s",
+}
+
+func TestPlanOf(t *testing.T) {
+	t.Parallel()
+	typ := reflect.TypeOf(ValidStruct{})
+
+	p, err := planOf(typ)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if cached, _ := planOf(typ); p != cached {
+		t.Errorf("Expected the plan of '%s' to be cached", typ)
+	}
+
+	var paths []string
+	for _, f := range p.fields[:3] {
+		paths = append(paths, f.Path)
+	}
+	expected := []string{"Home", "Jenkins.Workspace", "Jenkins.PointerMissing"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected field paths to be '%v' but got '%v'", expected, paths)
+	}
+	if !reflect.DeepEqual(p.fields[2].Index, []int{1, 1}) {
+		t.Errorf("Expected field index to be '%v' but got '%v'", []int{1, 1}, p.fields[2].Index)
+	}
+}
+
+func TestUnmarshalConcurrent(t *testing.T) {
+	t.Parallel()
+	type ConcurrentStruct struct {
+		Home   string `env:"HOME"`
+		Nested struct {
+			Int int `env:"INT"`
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var (
+				concurrentStruct ConcurrentStruct
+				flags            = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+			)
+			if err := Unmarshal(flags, EnvSet{"HOME": "/home/test", "INT": "1"}, &concurrentStruct); err != nil {
+				t.Errorf("Expected no error but got '%s'", err)
+			}
+			if concurrentStruct.Home != "/home/test" || concurrentStruct.Nested.Int != 1 {
+				t.Errorf("Expected fields to be set but got '%+v'", concurrentStruct)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	flags := flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	b.ReportAllocs()
+	for range b.N {
+		var validStruct ValidStruct
+		if err := Unmarshal(flags, benchmarkEnviron, &validStruct, PreserveEnvSet()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	validStruct := ValidStruct{Home: "/home/test", Int: 1, Duration: 5}
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := Marshal(&validStruct); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRegisterFlags(b *testing.B) {
+	var validStruct ValidStruct
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := RegisterFlags(&validStruct); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
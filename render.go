@@ -0,0 +1,162 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidName returned when a key of an EnvSet cannot be written as a
+// variable name in the requested format.
+type ErrInvalidName struct {
+	// Name is the offending EnvSet key
+	Name string
+}
+
+func (e ErrInvalidName) Error() string {
+	return fmt.Sprintf("%q is not a valid variable name", e.Name)
+}
+
+// WriteShellExports writes es to w as POSIX shell export statements, one per
+// line and sorted by key. Values are single quoted so spaces, newlines and
+// shell metacharacters are preserved as is. If a key is not a valid shell
+// variable name, WriteShellExports returns an ErrInvalidName.
+func WriteShellExports(w io.Writer, es EnvSet) error {
+	var b strings.Builder
+	for _, k := range sortedKeys(es) {
+		if !isShellName(k) {
+			return &ErrInvalidName{Name: k}
+		}
+		fmt.Fprintf(&b, "export %s=%s\n", k, shellQuote(es[k]))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSystemdEnvironmentFile writes es to w in the syntax of a systemd
+// EnvironmentFile, one assignment per line and sorted by key. Values are
+// double quoted, so they may span several lines. If a key is not a valid
+// variable name, WriteSystemdEnvironmentFile returns an ErrInvalidName.
+func WriteSystemdEnvironmentFile(w io.Writer, es EnvSet) error {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+	var b strings.Builder
+	for _, k := range sortedKeys(es) {
+		if !isShellName(k) {
+			return &ErrInvalidName{Name: k}
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"\n", k, escape.Replace(es[k]))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteComposeEnvironment writes es to w as the environment mapping of a
+// docker-compose service, sorted by key. A $ is written as $$, so compose
+// does not interpolate ${VAR} references in values.
+func WriteComposeEnvironment(w io.Writer, es EnvSet) error {
+	var b strings.Builder
+	b.WriteString("environment:\n")
+	for _, k := range sortedKeys(es) {
+		key := k
+		if !isShellName(k) {
+			key = yamlQuote(k)
+		}
+		fmt.Fprintf(&b, "  %s: %s\n", key, yamlQuote(escapeDollars(es[k])))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteKubernetesEnvYAML writes es to w as the env list of a Kubernetes
+// container in YAML, sorted by name. A $ is written as $$, so Kubernetes
+// does not expand $(VAR) references in values.
+func WriteKubernetesEnvYAML(w io.Writer, es EnvSet) error {
+	var b strings.Builder
+	b.WriteString("env:\n")
+	for _, k := range sortedKeys(es) {
+		fmt.Fprintf(&b, "  - name: %s\n", yamlQuote(k))
+		fmt.Fprintf(&b, "    value: %s\n", yamlQuote(escapeDollars(es[k])))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteKubernetesEnvJSON writes es to w as the env list of a Kubernetes
+// container in JSON, sorted by name. A $ is written as $$, like
+// WriteKubernetesEnvYAML does.
+func WriteKubernetesEnvJSON(w io.Writer, es EnvSet) error {
+	type envVar struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	vars := make([]envVar, 0, len(es))
+	for _, k := range sortedKeys(es) {
+		vars = append(vars, envVar{Name: k, Value: escapeDollars(es[k])})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(vars)
+}
+
+// escapeDollars doubles every $ of s, which both docker-compose and
+// Kubernetes read back as a single literal $.
+func escapeDollars(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+func sortedKeys(es EnvSet) []string {
+	keys := make([]string, 0, len(es))
+	for k := range es {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isShellName reports whether s is a valid POSIX shell variable name.
+func isShellName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// yamlQuote returns s as a YAML double quoted scalar. JSON strings are valid
+// YAML double quoted scalars, so the JSON encoding is reused.
+func yamlQuote(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	// encoding a string cannot fail
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var renderEnvSet = EnvSet{
+	"WORKSPACE": "/mnt/builds/slave/workspace/test",
+	"GREETING":  "it's a \"nice\" day\nisn't it",
+	"PRICE":     "$5 `now` & <later>",
+	"TEMPLATE":  "p${X} a$(HOME)",
+}
+
+func TestWriteShellExports(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := WriteShellExports(&b, renderEnvSet); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := `export GREETING='it'\''s a "nice" day
+isn'\''t it'
+export PRICE='$5 ` + "`now`" + ` & <later>'
+export TEMPLATE='p${X} a$(HOME)'
+export WORKSPACE='/mnt/builds/slave/workspace/test'
+`
+	if b.String() != expected {
+		t.Errorf("Expected output to be\n%s\nbut got\n%s", expected, b.String())
+	}
+}
+
+func TestWriteShellExportsInvalidName(t *testing.T) {
+	t.Parallel()
+	var (
+		b          strings.Builder
+		errInvalid *ErrInvalidName
+	)
+	if err := WriteShellExports(&b, EnvSet{"npm-config": "x"}); !errors.As(err, &errInvalid) {
+		t.Errorf("Expected error 'ErrInvalidName' but got '%s'", err)
+	}
+}
+
+func TestWriteSystemdEnvironmentFile(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := WriteSystemdEnvironmentFile(&b, renderEnvSet); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := `GREETING="it's a \"nice\" day
+isn't it"
+PRICE="\$5 ` + "\\`now\\`" + ` & <later>"
+TEMPLATE="p\${X} a\$(HOME)"
+WORKSPACE="/mnt/builds/slave/workspace/test"
+`
+	if b.String() != expected {
+		t.Errorf("Expected output to be\n%s\nbut got\n%s", expected, b.String())
+	}
+}
+
+func TestWriteComposeEnvironment(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	es := EnvSet{"npm-config": "a"}
+	for k, v := range renderEnvSet {
+		es[k] = v
+	}
+	if err := WriteComposeEnvironment(&b, es); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := `environment:
+  GREETING: "it's a \"nice\" day\nisn't it"
+  PRICE: "$$5 ` + "`now`" + ` & <later>"
+  TEMPLATE: "p$${X} a$$(HOME)"
+  WORKSPACE: "/mnt/builds/slave/workspace/test"
+  "npm-config": "a"
+`
+	if b.String() != expected {
+		t.Errorf("Expected output to be\n%s\nbut got\n%s", expected, b.String())
+	}
+}
+
+func TestWriteKubernetesEnvYAML(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := WriteKubernetesEnvYAML(&b, renderEnvSet); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := `env:
+  - name: "GREETING"
+    value: "it's a \"nice\" day\nisn't it"
+  - name: "PRICE"
+    value: "$$5 ` + "`now`" + ` & <later>"
+  - name: "TEMPLATE"
+    value: "p$${X} a$$(HOME)"
+  - name: "WORKSPACE"
+    value: "/mnt/builds/slave/workspace/test"
+`
+	if b.String() != expected {
+		t.Errorf("Expected output to be\n%s\nbut got\n%s", expected, b.String())
+	}
+}
+
+func TestWriteKubernetesEnvJSON(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := WriteKubernetesEnvJSON(&b, renderEnvSet); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	var vars []map[string]string
+	if err := json.Unmarshal([]byte(b.String()), &vars); err != nil {
+		t.Fatalf("Expected valid JSON but got '%s'", err)
+	}
+
+	expected := []map[string]string{
+		{"name": "GREETING", "value": renderEnvSet["GREETING"]},
+		{"name": "PRICE", "value": "$$5 `now` & <later>"},
+		{"name": "TEMPLATE", "value": "p$${X} a$$(HOME)"},
+		{"name": "WORKSPACE", "value": renderEnvSet["WORKSPACE"]},
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("Expected env to be '%v' but got '%v'", expected, vars)
+	}
+}
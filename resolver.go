@@ -0,0 +1,227 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Resolver finds the raw value of a field in the flags and EnvSet passed to
+// Unmarshal, applying its precedence rules. It is shared by Unmarshal and the
+// code generated by cmd/envgen so both resolve values identically; it is not
+// meant to be used directly.
+type Resolver struct {
+	// actual holds the flags that were set on the command line
+	actual  map[string]*flag.Flag
+	es      EnvSet
+	consume consumeMode
+	// consumed holds the keys deleted from es, which expansions may still
+	// reference
+	consumed EnvSet
+	// folded maps upper cased keys to the keys of es spelled that way
+	// regardless of case, sorted, when keys are matched case-insensitively
+	folded map[string][]string
+	// ambiguous is called when several keys of es match a key, once per key
+	ambiguous func(key string, matches []string)
+	warned    map[string]bool
+}
+
+// NewResolver returns a Resolver over the set flags of flags and over es.
+// Consumed keys are deleted from es.
+func NewResolver(flags *flag.FlagSet, es EnvSet) *Resolver {
+	return newResolver(flags, es, consumeSource)
+}
+
+func newResolver(flags *flag.FlagSet, es EnvSet, consume consumeMode) *Resolver {
+	// collect the flags that were actually set once instead of visiting the
+	// flag set for every lookup
+	actual := map[string]*flag.Flag{}
+	flags.Visit(func(f *flag.Flag) {
+		actual[f.Name] = f
+	})
+	return &Resolver{actual: actual, es: es, consume: consume}
+}
+
+// foldCase makes r match keys regardless of case, calling ambiguous, if not
+// nil, when several keys match.
+func (r *Resolver) foldCase(ambiguous func(key string, matches []string)) {
+	r.folded = make(map[string][]string, len(r.es))
+	for _, k := range sortedKeys(r.es) {
+		upper := strings.ToUpper(k)
+		r.folded[upper] = append(r.folded[upper], k)
+	}
+	r.ambiguous = ambiguous
+	r.warned = map[string]bool{}
+}
+
+// get returns the value of key in the EnvSet and the key it was found
+// under, which only differs from key in case when keys are matched
+// case-insensitively. The exact spelling wins over the others, which are
+// tried in byte order.
+func (r *Resolver) get(key string) (value, found string, ok bool) {
+	if r.folded == nil {
+		value, ok = r.es[key]
+		return value, key, ok
+	}
+
+	var matches []string
+	if _, ok := r.es[key]; ok {
+		matches = append(matches, key)
+	}
+	for _, k := range r.folded[strings.ToUpper(key)] {
+		if _, ok := r.es[k]; ok && k != key {
+			matches = append(matches, k)
+		}
+	}
+	if len(matches) == 0 {
+		return "", "", false
+	}
+	if len(matches) > 1 && r.ambiguous != nil && !r.warned[key] {
+		r.warned[key] = true
+		r.ambiguous(key, matches)
+	}
+	return r.es[matches[0]], matches[0], true
+}
+
+// Lookup returns the value of a field with custom flag flagName (possibly
+// empty), the flags keyFlags generated from its env keys, and env keys keys.
+// The custom flag wins over key flags, flags win over env keys, and env keys
+// over defaultValue. The env key that supplied the value, if any, is
+// returned as source.
+//
+// With expand, ${VAR} references in env values and defaultValue are
+// expanded against the EnvSet; flag values are taken as is.
+//
+// If nothing supplies a value ok is false, or an ErrMissingRequiredValue is
+// returned when required is set.
+func (r *Resolver) Lookup(flagName string, keyFlags, keys []string, defaultValue string, required, expand bool) (value, source string, ok bool, err error) {
+	// check if any flags are set, either the flag tag or the key flags
+	if flagName != "" {
+		if f, isSet := r.actual[flagName]; isSet {
+			return f.Value.String(), "", true, nil
+		}
+	}
+	for _, keyFlag := range keyFlags {
+		if f, isSet := r.actual[keyFlag]; isSet {
+			return f.Value.String(), "", true, nil
+		}
+	}
+
+	// if flag not set then check the env vars
+	for _, envKey := range keys {
+		if value, found, ok := r.get(envKey); ok {
+			if expand {
+				x := &expander{lookup: r.variable, stack: []string{found}}
+				if value, err = x.expand(value); err != nil {
+					return "", "", false, fmt.Errorf("expanding [%s]: %w", found, err)
+				}
+			}
+			return value, found, true, nil
+		}
+	}
+
+	if defaultValue != "" {
+		if expand {
+			x := &expander{lookup: r.variable}
+			if defaultValue, err = x.expand(defaultValue); err != nil {
+				return "", "", false, fmt.Errorf("expanding default of [%s]: %w", keys[0], err)
+			}
+		}
+		return defaultValue, "", true, nil
+	}
+	if required {
+		return "", "", false, &ErrMissingRequiredValue{Value: keys[0]}
+	}
+	return "", "", false, nil
+}
+
+// Present reports whether the custom flag flagName, one of keyFlags or one
+// of the env keys keys is set, ignoring defaults. Unmarshal allocates a nil
+// pointer to struct when one of the fields below it is present.
+func (r *Resolver) Present(flagName string, keyFlags, keys []string) bool {
+	if _, isSet := r.actual[flagName]; isSet && flagName != "" {
+		return true
+	}
+	for _, keyFlag := range keyFlags {
+		if _, isSet := r.actual[keyFlag]; isSet {
+			return true
+		}
+	}
+	for _, envKey := range keys {
+		if _, _, ok := r.get(envKey); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// anyPresent reports whether one of fields is present, see Present.
+func (r *Resolver) anyPresent(fields []field) bool {
+	for _, f := range fields {
+		if r.Present(f.Tag.Flag, f.KeyFlags, f.Tag.Keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// Consume deletes the keys of a field that was set from the EnvSet: source,
+// as returned by Lookup, or all of keys with the ConsumeAllKeys option.
+func (r *Resolver) Consume(source string, keys []string) {
+	switch r.consume {
+	case consumeSource:
+		if source != "" {
+			r.delete(source)
+		}
+	case consumeAllKeys:
+		for _, envKey := range keys {
+			r.delete(envKey)
+			for _, k := range r.folded[strings.ToUpper(envKey)] {
+				r.delete(k)
+			}
+		}
+	}
+}
+
+// delete removes key from the EnvSet, remembering its value for expansions.
+func (r *Resolver) delete(key string) {
+	value, ok := r.es[key]
+	if !ok {
+		return
+	}
+	if r.consumed == nil {
+		r.consumed = make(EnvSet)
+	}
+	r.consumed[key] = value
+	delete(r.es, key)
+}
+
+// variable looks up name for expansions, including consumed keys.
+func (r *Resolver) variable(name string) (string, bool) {
+	if value, _, ok := r.get(name); ok {
+		return value, true
+	}
+	if value, ok := r.consumed[name]; ok || r.folded == nil {
+		return value, ok
+	}
+	for _, k := range sortedKeys(r.consumed) {
+		if strings.EqualFold(k, name) {
+			return r.consumed[k], true
+		}
+	}
+	return "", false
+}
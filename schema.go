@@ -0,0 +1,146 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+const (
+	// jsonSchemaDialect is the JSON Schema draft the generated schemas declare
+	jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	// durationPattern matches the values accepted by time.ParseDuration
+	durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|μs|ms|s|m|h))+)$`
+)
+
+// jsonSchema is the subset of JSON Schema used to describe an environment.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Default     interface{}            `json:"default,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Minimum     *int                   `json:"minimum,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	AllOf       []*jsonSchema          `json:"allOf,omitempty"`
+	AnyOf       []*jsonSchema          `json:"anyOf,omitempty"`
+}
+
+// GenerateJSONSchema writes a JSON Schema (draft 2020-12) describing the
+// environment expected by the struct pointed to by v to w. Every env key
+// becomes a property typed after the Go kind of its field, with the default
+// and "desc" text of the field.
+//
+// Required fields without a default are listed as required. When such a
+// field has several keys, any one of them satisfies the schema.
+//
+// If v is nil or not a pointer to a struct, GenerateJSONSchema returns an
+// ErrInvalidValue.
+func GenerateJSONSchema(w io.Writer, v interface{}) error {
+	fields, err := collectFields(v)
+	if err != nil {
+		return err
+	}
+
+	schema := &jsonSchema{
+		Schema:     jsonSchemaDialect,
+		Type:       "object",
+		Properties: make(map[string]*jsonSchema),
+	}
+	for _, f := range fields {
+		for _, envKey := range f.Tag.Keys {
+			schema.Properties[envKey] = fieldSchema(f)
+		}
+
+		if !f.Tag.Required || f.Tag.Default != "" || len(f.Tag.Keys) == 0 {
+			continue
+		}
+		if len(f.Tag.Keys) == 1 {
+			schema.Required = append(schema.Required, f.Tag.Keys[0])
+			continue
+		}
+		anyKey := &jsonSchema{}
+		for _, envKey := range f.Tag.Keys {
+			anyKey.AnyOf = append(anyKey.AnyOf, &jsonSchema{Required: []string{envKey}})
+		}
+		schema.AllOf = append(schema.AllOf, anyKey)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// fieldSchema returns the schema of a single env key of f.
+func fieldSchema(f field) *jsonSchema {
+	s := &jsonSchema{Description: f.Tag.Desc}
+
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var parse func(string) (interface{}, error)
+	switch {
+	case t == reflect.TypeOf(ByteSize(0)):
+		s.Type = "string"
+		s.Pattern = byteSizePattern
+	case reflect.PointerTo(t).Implements(unmarshalType):
+		s.Type = "string"
+	case enumOf(t) != nil:
+		s.Type = "string"
+		s.Enum = enumOf(t).names
+	case t.PkgPath() == "time" && t.Name() == "Duration":
+		s.Type = "string"
+		s.Pattern = durationPattern
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			s.Type = "boolean"
+			parse = func(v string) (interface{}, error) { return strconv.ParseBool(v) }
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			s.Type = "integer"
+			parse = func(v string) (interface{}, error) { return ParseInt(v, t.Bits()) }
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			minimum := 0
+			s.Type = "integer"
+			s.Minimum = &minimum
+			parse = func(v string) (interface{}, error) { return ParseUint(v, t.Bits()) }
+		case reflect.Float32, reflect.Float64:
+			s.Type = "number"
+			parse = func(v string) (interface{}, error) { return strconv.ParseFloat(v, 64) }
+		default:
+			// strings, and slices holding their separator joined elements
+			s.Type = "string"
+		}
+	}
+
+	if f.Tag.Default != "" {
+		s.Default = f.Tag.Default
+		if parse != nil {
+			if v, err := parse(f.Tag.Default); err == nil {
+				s.Default = v
+			}
+		}
+	}
+
+	return s
+}
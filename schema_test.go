@@ -0,0 +1,96 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type SchemaStruct struct {
+	Port     int           `env:"PORT,default=80,desc=Port to listen on"`
+	Workers  uint          `env:"WORKERS"`
+	Ratio    float64       `env:"RATIO,default=0.5"`
+	Debug    *bool         `env:"DEBUG,default=false"`
+	Timeout  time.Duration `env:"TIMEOUT,default=5s"`
+	Hosts    []string      `env:"HOSTS,required=true"`
+	Token    string        `env:"TOKEN,API_TOKEN,required=true"`
+	Fallback string        `env:"FALLBACK,required=true,default=none"`
+	JSONData JSONData      `env:"JSON_DATA"`
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateJSONSchema(&b, &SchemaStruct{}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(b.String()), &schema); err != nil {
+		t.Fatalf("Expected valid JSON but got '%s'", err)
+	}
+
+	if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+		t.Errorf("Expected draft 2020-12 but got '%v'", schema["$schema"])
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	testCases := []struct {
+		key      string
+		expected map[string]interface{}
+	}{
+		{"PORT", map[string]interface{}{"type": "integer", "default": float64(80), "description": "Port to listen on"}},
+		{"WORKERS", map[string]interface{}{"type": "integer", "minimum": float64(0)}},
+		{"RATIO", map[string]interface{}{"type": "number", "default": 0.5}},
+		{"DEBUG", map[string]interface{}{"type": "boolean", "default": false}},
+		{"TIMEOUT", map[string]interface{}{"type": "string", "default": "5s", "pattern": durationPattern}},
+		{"HOSTS", map[string]interface{}{"type": "string"}},
+		{"API_TOKEN", map[string]interface{}{"type": "string"}},
+		{"FALLBACK", map[string]interface{}{"type": "string", "default": "none"}},
+		{"JSON_DATA", map[string]interface{}{"type": "string"}},
+	}
+	for _, testCase := range testCases {
+		if !reflect.DeepEqual(properties[testCase.key], testCase.expected) {
+			t.Errorf("Expected property '%s' to be '%v' but got '%v'", testCase.key, testCase.expected, properties[testCase.key])
+		}
+	}
+
+	if !reflect.DeepEqual(schema["required"], []interface{}{"HOSTS"}) {
+		t.Errorf("Expected required to be '%v' but got '%v'", []string{"HOSTS"}, schema["required"])
+	}
+
+	expectedAllOf := []interface{}{
+		map[string]interface{}{"anyOf": []interface{}{
+			map[string]interface{}{"required": []interface{}{"TOKEN"}},
+			map[string]interface{}{"required": []interface{}{"API_TOKEN"}},
+		}},
+	}
+	if !reflect.DeepEqual(schema["allOf"], expectedAllOf) {
+		t.Errorf("Expected allOf to be '%v' but got '%v'", expectedAllOf, schema["allOf"])
+	}
+}
+
+func TestGenerateJSONSchemaInvalid(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := GenerateJSONSchema(&b, SchemaStruct{}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected error 'ErrInvalidValue' but got '%s'", err)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Source loads an EnvSet, for example from a file. A Watcher loads its
+// Source again on every reload, so Load must return a fresh EnvSet each time.
+type Source interface {
+	Load() (EnvSet, error)
+}
+
+// SourceFunc adapts an ordinary function to a Source.
+type SourceFunc func() (EnvSet, error)
+
+// Load calls f.
+func (f SourceFunc) Load() (EnvSet, error) {
+	return f()
+}
+
+// EnvironSource returns a Source reading the environment of the process.
+func EnvironSource() Source {
+	return SourceFunc(func() (EnvSet, error) {
+		return EnvironToEnvSet(os.Environ())
+	})
+}
+
+// FileSource returns a Source reading the env file at path, one KEY=value
+// assignment per line. Blank lines and lines starting with # are skipped and
+// a leading "export" is allowed, so the output of WriteShellExports,
+// WriteSystemdEnvironmentFile and GenerateDotenvExample can be read back.
+//
+// Values may be single quoted, taken literally, or double quoted, where a
+// backslash escapes the next character and \n is a newline. Quoted values
+// may span several lines. Unquoted values end at a " #" comment and have
+// surrounding spaces trimmed.
+func FileSource(path string) Source {
+	return SourceFunc(func() (EnvSet, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		es, err := parseEnvFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%w", path, err)
+		}
+		return es, nil
+	})
+}
+
+// Chain returns a Source merging the EnvSets of sources. When several of
+// them hold a key, the earliest source wins, so
+//
+//	Chain(EnvironSource(), DirSource("/etc/secrets"))
+//
+// lets the environment override the mounted files.
+func Chain(sources ...Source) Source {
+	return SourceFunc(func() (EnvSet, error) {
+		es := make(EnvSet)
+		for i := len(sources) - 1; i >= 0; i-- {
+			layer, err := sources[i].Load()
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range layer {
+				es[k] = v
+			}
+		}
+		return es, nil
+	})
+}
+
+// DirOption configures DirSource.
+type DirOption func(*dirOptions)
+
+type dirOptions struct {
+	// mappers turn file names into keys, applied in order
+	mappers []func(string) string
+}
+
+// UpperCaseKeys makes DirSource upper-case file names, so a file named
+// db_host becomes DB_HOST.
+func UpperCaseKeys() DirOption {
+	return MapKeys(strings.ToUpper)
+}
+
+// DashesToUnderscores makes DirSource replace dashes in file names with
+// underscores, so a file named db-host becomes db_host.
+func DashesToUnderscores() DirOption {
+	return MapKeys(func(name string) string {
+		return strings.ReplaceAll(name, "-", "_")
+	})
+}
+
+// MapKeys makes DirSource turn file names into keys with fn, after the
+// mappings of the options before it.
+func MapKeys(fn func(name string) string) DirOption {
+	return func(o *dirOptions) {
+		o.mappers = append(o.mappers, fn)
+	}
+}
+
+// DirSource returns a Source reading a directory holding one file per key,
+// as Kubernetes mounts configmaps and secrets. The file name is the key and
+// the contents, without one trailing newline, the value.
+//
+// Symbolic links are followed, subdirectories are skipped and so are hidden
+// names such as the ..data link and the timestamped directories Kubernetes
+// uses to update the files atomically.
+func DirSource(path string, opts ...DirOption) Source {
+	o := &dirOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return SourceFunc(func() (EnvSet, error) {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		es := make(EnvSet, len(entries))
+		for _, entry := range entries {
+			name := entry.Name()
+			if strings.HasPrefix(name, ".") {
+				continue
+			}
+
+			file := filepath.Join(path, name)
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			value := strings.TrimSuffix(string(data), "\n")
+			value = strings.TrimSuffix(value, "\r")
+			for _, mapper := range o.mappers {
+				name = mapper(name)
+			}
+			es[name] = value
+		}
+		return es, nil
+	})
+}
+
+// parseEnvFile parses the contents of an env file. Errors are prefixed with
+// the line number they occur on.
+func parseEnvFile(data string) (EnvSet, error) {
+	es := make(EnvSet)
+	p := &envFileParser{data: data, line: 1}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return es, nil
+		}
+
+		line := p.line
+		key, value, err := p.assignment()
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", line, err)
+		}
+		es[key] = value
+	}
+}
+
+// envFileParser scans an env file one assignment at a time.
+type envFileParser struct {
+	data string
+	pos  int
+	// line is the line number of pos, for error messages
+	line int
+}
+
+func (p *envFileParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+// next returns the next byte and advances past it.
+func (p *envFileParser) next() byte {
+	c := p.data[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipBlank skips whitespace, empty lines and comment lines.
+func (p *envFileParser) skipBlank() {
+	for !p.eof() {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.next()
+		case '#':
+			for !p.eof() && p.data[p.pos] != '\n' {
+				p.next()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// assignment parses a single KEY=value line, including any quoted value
+// continuing on the following lines.
+func (p *envFileParser) assignment() (string, string, error) {
+	if strings.HasPrefix(p.data[p.pos:], "export ") || strings.HasPrefix(p.data[p.pos:], "export\t") {
+		p.pos += len("export")
+		for !p.eof() && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+			p.next()
+		}
+	}
+
+	start := p.pos
+	for !p.eof() && p.data[p.pos] != '=' && p.data[p.pos] != '\n' {
+		p.next()
+	}
+	key := strings.TrimSpace(p.data[start:p.pos])
+	if p.eof() || p.data[p.pos] != '=' {
+		return "", "", fmt.Errorf("%q must have format key=value", key)
+	}
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+	p.next()
+
+	var (
+		value strings.Builder
+		// trimmed is the length of value without trailing unquoted spaces
+		trimmed int
+	)
+	for !p.eof() && value.Len() == 0 && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.next()
+	}
+	for !p.eof() {
+		c := p.next()
+		switch c {
+		case '\n':
+			return key, value.String()[:trimmed], nil
+		case '\'':
+			end := strings.IndexByte(p.data[p.pos:], '\'')
+			if end < 0 {
+				return "", "", fmt.Errorf("unterminated single quoted value of %s", key)
+			}
+			for end > 0 {
+				value.WriteByte(p.next())
+				end--
+			}
+			p.next()
+		case '"':
+			for {
+				if p.eof() {
+					return "", "", fmt.Errorf("unterminated double quoted value of %s", key)
+				}
+				c := p.next()
+				if c == '"' {
+					break
+				}
+				if c == '\\' && !p.eof() {
+					c = p.next()
+					if c == 'n' {
+						c = '\n'
+					}
+				}
+				value.WriteByte(c)
+			}
+		case '\\':
+			if !p.eof() && p.data[p.pos] != '\n' {
+				c = p.next()
+			}
+			value.WriteByte(c)
+		case '#':
+			if value.Len() > trimmed || value.Len() == 0 {
+				// a comment starts after unquoted whitespace
+				for !p.eof() && p.data[p.pos] != '\n' {
+					p.next()
+				}
+				continue
+			}
+			value.WriteByte(c)
+		case ' ', '\t', '\r':
+			value.WriteByte(c)
+			continue
+		default:
+			value.WriteByte(c)
+		}
+		trimmed = value.Len()
+	}
+	return key, value.String()[:trimmed], nil
+}
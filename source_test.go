@@ -0,0 +1,197 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	t.Parallel()
+	data := `# database
+DB_HOST=db.internal
+export DB_PORT = 5432  # inline comment
+DB_PASS='p#ss word'
+GREETING="hello \"world\"\nbye"
+MULTI="first
+second"
+EMPTY=
+HASH=a#b
+ESCAPED=a\ b
+`
+	es, err := parseEnvFile(data)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := EnvSet{
+		"DB_HOST":  "db.internal",
+		"DB_PORT":  "5432",
+		"DB_PASS":  "p#ss word",
+		"GREETING": "hello \"world\"\nbye",
+		"MULTI":    "first\nsecond",
+		"EMPTY":    "",
+		"HASH":     "a#b",
+		"ESCAPED":  "a b",
+	}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected EnvSet to be '%v' but got '%v'", expected, es)
+	}
+}
+
+func TestParseEnvFileInvalid(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"missing equals": "A=1\nB\n",
+		"space in key":   "A=1\nMY KEY=1\n",
+		"unterminated":   "A=1\nB='open\n",
+	}
+	for name, data := range tests {
+		if _, err := parseEnvFile(data); err == nil || !strings.HasPrefix(err.Error(), "2: ") {
+			t.Errorf("%s: Expected an error on line 2 but got '%v'", name, err)
+		}
+	}
+}
+
+func TestFileSourceRoundTrip(t *testing.T) {
+	t.Parallel()
+	writers := map[string]func(*strings.Builder, EnvSet) error{
+		"shell":   func(b *strings.Builder, es EnvSet) error { return WriteShellExports(b, es) },
+		"systemd": func(b *strings.Builder, es EnvSet) error { return WriteSystemdEnvironmentFile(b, es) },
+	}
+	for name, write := range writers {
+		var b strings.Builder
+		if err := write(&b, renderEnvSet); err != nil {
+			t.Fatalf("%s: Expected no error but got '%s'", name, err)
+		}
+		path := filepath.Join(t.TempDir(), name+".env")
+		if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		es, err := FileSource(path).Load()
+		if err != nil {
+			t.Fatalf("%s: Expected no error but got '%s'", name, err)
+		}
+		if !reflect.DeepEqual(es, renderEnvSet) {
+			t.Errorf("%s: Expected EnvSet to be '%v' but got '%v'", name, renderEnvSet, es)
+		}
+	}
+
+	type DotenvStruct struct {
+		Plain   string `env:"PLAIN,default=/tmp/x"`
+		Quote   string `env:"QUOTE,default=it's \"fine\""`
+		Comment string `env:"COMMENT,default=a #b"`
+		Escape  string `env:"ESCAPE,default=C:\\dir"`
+		Spaces  string `env:"SPACES,default= padded "`
+		Empty   string `env:"EMPTY"`
+	}
+	var b strings.Builder
+	if err := GenerateDotenvExample(&DotenvStruct{}, &b); err != nil {
+		t.Fatalf("dotenv: Expected no error but got '%s'", err)
+	}
+	path := filepath.Join(t.TempDir(), "dotenv.env")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	es, err := FileSource(path).Load()
+	if err != nil {
+		t.Fatalf("dotenv: Expected no error but got '%s'", err)
+	}
+	expected := EnvSet{"PLAIN": "/tmp/x", "QUOTE": `it's "fine"`, "COMMENT": "a #b", "ESCAPE": `C:\dir`, "SPACES": " padded ", "EMPTY": ""}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("dotenv: Expected EnvSet to be '%v' but got '%v'", expected, es)
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	t.Parallel()
+	// lay the directory out as Kubernetes does: the files live in a
+	// timestamped directory and are reached through the ..data link
+	dir := t.TempDir()
+	data := filepath.Join(dir, "..2026_10_18_11_29_06.123456789")
+	if err := os.Mkdir(data, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"db-host":     "db.internal\n",
+		"db-password": "s3cret",
+		"banner":      "line one\nline two\n\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(data, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Base(data), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	for name := range files {
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	es, err := DirSource(dir).Load()
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := EnvSet{
+		"db-host":     "db.internal",
+		"db-password": "s3cret",
+		"banner":      "line one\nline two\n",
+	}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected EnvSet to be '%v' but got '%v'", expected, es)
+	}
+
+	es, err = DirSource(dir, DashesToUnderscores(), UpperCaseKeys()).Load()
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if es["DB_HOST"] != "db.internal" || es["DB_PASSWORD"] != "s3cret" || len(es) != 3 {
+		t.Errorf("Expected mapped keys but got '%v'", es)
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+	env := SourceFunc(func() (EnvSet, error) {
+		return EnvSet{"DB_HOST": "override"}, nil
+	})
+	files := SourceFunc(func() (EnvSet, error) {
+		return EnvSet{"DB_HOST": "db.internal", "DB_PASSWORD": "s3cret"}, nil
+	})
+
+	es, err := Chain(env, files).Load()
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := EnvSet{"DB_HOST": "override", "DB_PASSWORD": "s3cret"}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected EnvSet to be '%v' but got '%v'", expected, es)
+	}
+
+	if _, err := Chain(env, DirSource(filepath.Join(t.TempDir(), "missing"))).Load(); !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error but got '%v'", err)
+	}
+}
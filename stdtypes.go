@@ -0,0 +1,134 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	locationType = reflect.TypeOf((*time.Location)(nil))
+	urlType      = reflect.TypeOf(url.URL{})
+	addrType     = reflect.TypeOf(netip.Addr{})
+	prefixType   = reflect.TypeOf(netip.Prefix{})
+	regexpType   = reflect.TypeOf((*regexp.Regexp)(nil))
+)
+
+// timeLayouts maps the names of the layouts of the time package to their
+// value, so a tag can say layout=RFC1123 instead of spelling out a layout
+// holding commas.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// isStdType reports whether t is one of the standard library types set and
+// format support natively. Those that are structs are values, not nested
+// config structs.
+func isStdType(t reflect.Type) bool {
+	switch t {
+	case timeType, locationType, urlType, addrType, prefixType, regexpType:
+		return true
+	}
+	return false
+}
+
+// setStdType parses value into f of the standard library type t, see
+// isStdType.
+func setStdType(t reflect.Type, f reflect.Value, value string, envTag tag) error {
+	var v interface{}
+	var err error
+	switch t {
+	case timeType:
+		layout := envTag.Layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		v, err = time.Parse(layout, value)
+	case locationType:
+		v, err = time.LoadLocation(value)
+	case urlType:
+		var u *url.URL
+		if u, err = url.Parse(value); err == nil {
+			v = *u
+		}
+	case addrType:
+		// like netip.Addr.UnmarshalText, an empty value is the zero Addr
+		var addr netip.Addr
+		err = addr.UnmarshalText([]byte(value))
+		v = addr
+	case prefixType:
+		var prefix netip.Prefix
+		err = prefix.UnmarshalText([]byte(value))
+		v = prefix
+	case regexpType:
+		v, err = regexp.Compile(value)
+	default:
+		return ErrUnsupportedType
+	}
+	if err != nil {
+		return err
+	}
+	f.Set(reflect.ValueOf(v))
+	return nil
+}
+
+// formatStdType is the inverse of setStdType.
+func formatStdType(v reflect.Value, envTag tag) (string, error) {
+	switch v.Type() {
+	case timeType:
+		layout := envTag.Layout
+		if layout == "" {
+			// parsed back by time.RFC3339, which accepts fractional seconds
+			layout = time.RFC3339Nano
+		}
+		return v.Interface().(time.Time).Format(layout), nil
+	case locationType:
+		return v.Interface().(*time.Location).String(), nil
+	case urlType:
+		u := v.Interface().(url.URL)
+		return u.String(), nil
+	case addrType:
+		b, err := v.Interface().(netip.Addr).MarshalText()
+		return string(b), err
+	case prefixType:
+		b, err := v.Interface().(netip.Prefix).MarshalText()
+		return string(b), err
+	case regexpType:
+		return v.Interface().(*regexp.Regexp).String(), nil
+	}
+	return "", ErrUnsupportedType
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"flag"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type StdTypesStruct struct {
+	Started   time.Time        `env:"STARTED"`
+	Expires   time.Time        `env:"EXPIRES,layout=DateOnly"`
+	Holidays  []time.Time      `env:"HOLIDAYS,layout=02/01/2006,separator=;"`
+	Zone      *time.Location   `env:"TZ,default=UTC"`
+	Upstream  *url.URL         `env:"UPSTREAM"`
+	Mirrors   []url.URL        `env:"MIRRORS,separator= "`
+	Listen    netip.Addr       `env:"LISTEN"`
+	Allowlist []netip.Prefix   `env:"ALLOWLIST"`
+	Filter    *regexp.Regexp   `env:"FILTER"`
+	Excludes  []*regexp.Regexp `env:"EXCLUDES"`
+}
+
+func TestUnmarshalStdTypes(t *testing.T) {
+	t.Parallel()
+	es := EnvSet{
+		"STARTED":   "2026-03-01T10:30:00.5+01:00",
+		"EXPIRES":   "2027-01-31",
+		"HOLIDAYS":  "25/12/2026;01/01/2027",
+		"UPSTREAM":  "https://user@api.example.com:8443/v1?debug=1",
+		"MIRRORS":   "https://a.example.com https://b.example.com/pkg",
+		"LISTEN":    "::1",
+		"ALLOWLIST": "10.0.0.0/8|192.168.1.0/24|fd00::/8",
+		"FILTER":    `^/api/v[0-9]+/`,
+		"EXCLUDES":  `\.tmp$|^\.`,
+	}
+
+	var stdTypesStruct StdTypesStruct
+	flags, err := RegisterFlags(&stdTypesStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := flags.Parse([]string{"-tz=Europe/Paris"}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := Unmarshal(flags, es, &stdTypesStruct); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if want := time.Date(2026, 3, 1, 9, 30, 0, 5e8, time.UTC); !stdTypesStruct.Started.Equal(want) {
+		t.Errorf("Expected Started to be '%s' but got '%s'", want, stdTypesStruct.Started)
+	}
+	if want := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC); !stdTypesStruct.Expires.Equal(want) {
+		t.Errorf("Expected Expires to be '%s' but got '%s'", want, stdTypesStruct.Expires)
+	}
+	if len(stdTypesStruct.Holidays) != 2 || stdTypesStruct.Holidays[0].Month() != time.December {
+		t.Errorf("Unexpected Holidays '%v'", stdTypesStruct.Holidays)
+	}
+	if stdTypesStruct.Zone == nil || stdTypesStruct.Zone.String() != "Europe/Paris" {
+		t.Errorf("Expected Zone to be 'Europe/Paris' but got '%v'", stdTypesStruct.Zone)
+	}
+	if u := stdTypesStruct.Upstream; u == nil || u.Hostname() != "api.example.com" || u.Port() != "8443" || u.User.Username() != "user" {
+		t.Errorf("Unexpected Upstream '%v'", u)
+	}
+	if len(stdTypesStruct.Mirrors) != 2 || stdTypesStruct.Mirrors[1].Path != "/pkg" {
+		t.Errorf("Unexpected Mirrors '%v'", stdTypesStruct.Mirrors)
+	}
+	if stdTypesStruct.Listen != netip.IPv6Loopback() {
+		t.Errorf("Expected Listen to be '::1' but got '%s'", stdTypesStruct.Listen)
+	}
+	if len(stdTypesStruct.Allowlist) != 3 || !stdTypesStruct.Allowlist[1].Contains(netip.MustParseAddr("192.168.1.7")) {
+		t.Errorf("Unexpected Allowlist '%v'", stdTypesStruct.Allowlist)
+	}
+	if f := stdTypesStruct.Filter; f == nil || !f.MatchString("/api/v2/users") || f.MatchString("/v2") {
+		t.Errorf("Unexpected Filter '%v'", f)
+	}
+	if len(stdTypesStruct.Excludes) != 2 || !stdTypesStruct.Excludes[1].MatchString(".git") {
+		t.Errorf("Unexpected Excludes '%v'", stdTypesStruct.Excludes)
+	}
+}
+
+func TestUnmarshalStdTypesInvalid(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"STARTED":   `parsing time "2026" as "2006-01-02T15:04:05Z07:00"`,
+		"EXPIRES":   "extra text",
+		"TZ":        "unknown time zone Mars/Olympus",
+		"UPSTREAM":  "invalid URL escape",
+		"LISTEN":    "unable to parse IP",
+		"ALLOWLIST": `no '/'`,
+		"FILTER":    "missing closing )",
+	}
+	values := map[string]string{
+		"STARTED":   "2026",
+		"EXPIRES":   "2027-01-31T00:00:00Z",
+		"TZ":        "Mars/Olympus",
+		"UPSTREAM":  "http://example.com/%zz",
+		"LISTEN":    "localhost",
+		"ALLOWLIST": "10.0.0.0/8|10.0.0.1",
+		"FILTER":    "(",
+	}
+	for key, reason := range tests {
+		var stdTypesStruct StdTypesStruct
+		es := EnvSet{key: values[key]}
+		err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), es, &stdTypesStruct)
+		if err == nil || !strings.Contains(err.Error(), reason) {
+			t.Errorf("%s: Expected error '%s' but got '%v'", key, reason, err)
+		}
+	}
+}
+
+func TestMarshalStdTypes(t *testing.T) {
+	t.Parallel()
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdTypesStruct := StdTypesStruct{
+		Started:   time.Date(2026, 3, 1, 10, 30, 0, 5e8, paris),
+		Expires:   time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC),
+		Holidays:  []time.Time{time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)},
+		Zone:      paris,
+		Upstream:  &url.URL{Scheme: "https", Host: "api.example.com", Path: "/v1"},
+		Mirrors:   []url.URL{{Scheme: "https", Host: "a.example.com"}},
+		Allowlist: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")},
+		Excludes:  []*regexp.Regexp{regexp.MustCompile(`\.tmp$`)},
+	}
+
+	es, err := Marshal(&stdTypesStruct)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := EnvSet{
+		"STARTED":   "2026-03-01T10:30:00.5+01:00",
+		"EXPIRES":   "2027-01-31",
+		"HOLIDAYS":  "25/12/2026",
+		"TZ":        "Europe/Paris",
+		"UPSTREAM":  "https://api.example.com/v1",
+		"MIRRORS":   "https://a.example.com",
+		"LISTEN":    "",
+		"ALLOWLIST": "10.0.0.0/8|fd00::/8",
+		"EXCLUDES":  `\.tmp$$`,
+	}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Expected '%v' but got '%v'", expected, es)
+	}
+
+	var roundTrip StdTypesStruct
+	if err := Unmarshal(flag.NewFlagSet(testEnvFlagSetName, flag.ContinueOnError), es, &roundTrip); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if !roundTrip.Started.Equal(stdTypesStruct.Started) || roundTrip.Zone.String() != "Europe/Paris" ||
+		roundTrip.Upstream.String() != stdTypesStruct.Upstream.String() || roundTrip.Listen.IsValid() ||
+		!reflect.DeepEqual(roundTrip.Allowlist, stdTypesStruct.Allowlist) || roundTrip.Excludes[0].String() != `\.tmp$` {
+		t.Errorf("Expected '%+v' to round trip but got '%+v'", stdTypesStruct, roundTrip)
+	}
+}
+
+func TestParseTagLayout(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		`A,layout=RFC1123`:          time.RFC1123,
+		`A,layout=2006-01-02 15:04`: "2006-01-02 15:04",
+		`A,layout='Jan 2, 2006'`:    "Jan 2, 2006",
+	}
+	for tagString, expected := range tests {
+		got, err := parseTag(tagString)
+		if err != nil {
+			t.Errorf("%s: Expected no error but got '%s'", tagString, err)
+		} else if got.Layout != expected {
+			t.Errorf("%s: Expected layout '%s' but got '%s'", tagString, expected, got.Layout)
+		}
+	}
+}
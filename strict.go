@@ -0,0 +1,190 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownKey returned in strict mode when the EnvSet holds a key under a
+// strict prefix that no field reads.
+type ErrUnknownKey struct {
+	// Key is the unknown environment variable
+	Key string
+	// Suggestion is the closest known key, if any is close enough
+	Suggestion string
+}
+
+func (e ErrUnknownKey) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown environment variable [%s], did you mean [%s]?", e.Key, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown environment variable [%s]", e.Key)
+}
+
+// ErrUnknownFlag returned in strict mode when a command line flag is not
+// defined by the config struct.
+type ErrUnknownFlag struct {
+	// Flag is the unknown flag name, without leading dashes
+	Flag string
+	// Suggestion is the closest defined flag, if any is close enough
+	Suggestion string
+}
+
+func (e ErrUnknownFlag) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown flag [-%s], did you mean [-%s]?", e.Flag, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown flag [-%s]", e.Flag)
+}
+
+// checkUnknownFlags returns the joined ErrUnknownFlag errors of the flags in
+// args that are not defined in flags.
+func checkUnknownFlags(flags *flag.FlagSet, args []string) error {
+	undefined := undefinedFlags(flags, args)
+	if len(undefined) == 0 {
+		return nil
+	}
+
+	var known []string
+	flags.VisitAll(func(f *flag.Flag) {
+		known = append(known, f.Name)
+	})
+
+	errs := make([]error, 0, len(undefined))
+	for _, name := range undefined {
+		errs = append(errs, &ErrUnknownFlag{Flag: name, Suggestion: suggest(name, known)})
+	}
+	return errors.Join(errs...)
+}
+
+// checkUnknownKeys returns the joined ErrUnknownKey errors of the keys in es
+// that start with one of prefixes but are not read by any field of the
+// struct rv, or of the structs its interface fields point to. With foldCase,
+// keys and prefixes match regardless of case, see CaseInsensitive.
+func checkUnknownKeys(es EnvSet, rv reflect.Value, prefixes []string, foldCase bool) error {
+	known := map[string]bool{}
+	var knownKeys []string
+	if err := addKnownKeys(rv, known, &knownKeys); err != nil {
+		return err
+	}
+
+	canonical := func(s string) string { return s }
+	if foldCase {
+		canonical = strings.ToUpper
+		for k := range known {
+			known[canonical(k)] = true
+		}
+		folded := make([]string, len(prefixes))
+		for i, prefix := range prefixes {
+			folded[i] = canonical(prefix)
+		}
+		prefixes = folded
+	}
+
+	var errs []error
+	for _, k := range sortedKeys(es) {
+		if known[canonical(k)] || !hasAnyPrefix(canonical(k), prefixes) {
+			continue
+		}
+		errs = append(errs, &ErrUnknownKey{Key: k, Suggestion: suggest(canonical(k), knownKeys)})
+	}
+	return errors.Join(errs...)
+}
+
+// addKnownKeys adds the env keys of the fields of the struct rv to known
+// and knownKeys, then those of the structs its interface fields point to.
+func addKnownKeys(rv reflect.Value, known map[string]bool, knownKeys *[]string) error {
+	p, err := planOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range p.fields {
+		for _, envKey := range f.Tag.Keys {
+			if !known[envKey] {
+				known[envKey] = true
+				*knownKeys = append(*knownKeys, envKey)
+			}
+		}
+	}
+	for _, sv := range p.ifaceStructs(rv) {
+		if err := addKnownKeys(sv, known, knownKeys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns the candidate closest to name, or an empty string when
+// none is within a third of the length of name, with at least two edits
+// always allowed. Ties are broken alphabetically.
+func suggest(name string, candidates []string) string {
+	sorted := append([]string(nil), candidates...)
+	sort.Strings(sorted)
+
+	best, bestDistance := "", max(2, len(name)/3)+1
+	for _, candidate := range sorted {
+		if d := editDistance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the optimal string alignment distance between a and b,
+// the Levenshtein distance with transposition of adjacent characters
+// counted as a single edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// rows i-2, i-1 and i of the distance matrix
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
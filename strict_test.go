@@ -0,0 +1,127 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+type BillingStruct struct {
+	Port int    `env:"BILLING_PORT,default=8080"`
+	Host string `env:"BILLING_HOST"`
+}
+
+func TestUnmarshalStrictUnknownKey(t *testing.T) {
+	t.Parallel()
+	var (
+		environ = map[string]string{
+			"BILLING_PROT": "9090",
+			"BILLING_HOST": "localhost",
+			"HOME":         "/home/test",
+		}
+		billingStruct BillingStruct
+		flags         = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	)
+
+	err := Unmarshal(flags, environ, &billingStruct, Strict("BILLING_"))
+	var errUnknown *ErrUnknownKey
+	if !errors.As(err, &errUnknown) {
+		t.Fatalf("Expected error 'ErrUnknownKey' but got '%s'", err)
+	}
+	if errUnknown.Key != "BILLING_PROT" || errUnknown.Suggestion != "BILLING_PORT" {
+		t.Errorf("Expected unknown key '%s' with suggestion '%s' but got '%s'", "BILLING_PROT", "BILLING_PORT", err)
+	}
+	if err.Error() != "unknown environment variable [BILLING_PROT], did you mean [BILLING_PORT]?" {
+		t.Errorf("Expected a did you mean message but got '%s'", err)
+	}
+
+	delete(environ, "BILLING_PROT")
+	if err := Unmarshal(flags, environ, &billingStruct, Strict("BILLING_")); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+}
+
+func TestUnmarshalNotStrict(t *testing.T) {
+	t.Parallel()
+	var (
+		environ       = map[string]string{"BILLING_PROT": "9090"}
+		billingStruct BillingStruct
+		flags         = flag.NewFlagSet(testEnvFlagSetName, flag.ExitOnError)
+	)
+
+	if err := Unmarshal(flags, environ, &billingStruct); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+	if billingStruct.Port != 8080 {
+		t.Errorf("Expected field value to be '%d' but got '%d'", 8080, billingStruct.Port)
+	}
+}
+
+func TestCheckUnknownFlags(t *testing.T) {
+	t.Parallel()
+	var billingStruct BillingStruct
+	flags, err := RegisterFlags(&billingStruct)
+	if err != nil {
+		t.Errorf("Expected no error while register but got '%s'", err)
+	}
+
+	args := []string{"-billing-prot", "9090", "--billing-host=localhost", "-verbose=true", "-h"}
+	err = checkUnknownFlags(flags, args)
+
+	expected := "unknown flag [-billing-prot], did you mean [-billing-port]?\nunknown flag [-verbose]"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error '%s' but got '%v'", expected, err)
+	}
+
+	var errUnknown *ErrUnknownFlag
+	if !errors.As(err, &errUnknown) {
+		t.Errorf("Expected error 'ErrUnknownFlag' but got '%s'", err)
+	}
+
+	if err := checkUnknownFlags(flags, []string{"-billing-port", "9090"}); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+
+	// - is a positional argument and nothing after -- is a flag
+	if err := checkUnknownFlags(flags, []string{"-billing-port=9090", "-", "--", "-verbose"}); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+	if err := checkUnknownFlags(flags, []string{"-", "-verbose"}); err == nil || err.Error() != "unknown flag [-verbose]" {
+		t.Errorf("Expected error 'unknown flag [-verbose]' but got '%v'", err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"PORT", "PORT", 0},
+		{"PORT", "PROT", 1},
+		{"PORT", "PORTS", 1},
+		{"PORT", "HOST", 2},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, testCase := range testCases {
+		if d := editDistance(testCase.a, testCase.b); d != testCase.expected {
+			t.Errorf("Expected distance between '%s' and '%s' to be %d but got %d", testCase.a, testCase.b, testCase.expected, d)
+		}
+	}
+}
@@ -1,8 +1,18 @@
 package env
 
+import "flag"
+
 // Unmarshaler is the interface implemented by types that can unmarshal an
 // environment variable value representation of themselves. The input can be
 // assumed to be the raw string value stored in the environment.
 type Unmarshaler interface {
 	UnmarshalEnvironmentValue(data string) error
 }
+
+// EnvUnmarshaler is implemented by config structs with generated
+// unmarshalling code, see cmd/envgen. Unmarshal calls UnmarshalEnv instead of
+// walking the struct with reflection, unless an option needs the reflective
+// path.
+type EnvUnmarshaler interface {
+	UnmarshalEnv(flags *flag.FlagSet, es EnvSet) error
+}
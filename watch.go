@@ -0,0 +1,372 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Change describes a field whose value changed on reload.
+type Change struct {
+	// Path is the dotted path of the field in the config struct
+	Path string
+	// Keys are the env keys of the field
+	Keys []string
+	// Old and New are the field values before and after the reload, nil
+	// for a field below a nil pointer to struct
+	Old, New interface{}
+}
+
+// reloadTarget holds the config updated by a Watcher.
+type reloadTarget interface {
+	// value returns a pointer to the current config, which must not be
+	// modified
+	value() reflect.Value
+	// swap makes the struct pointed to by next the current config
+	swap(next reflect.Value)
+	// check returns an error if the config cannot be reloaded
+	check() error
+}
+
+// structTarget updates a config struct in place.
+type structTarget struct {
+	v reflect.Value
+}
+
+func (t structTarget) value() reflect.Value {
+	return t.v
+}
+
+func (t structTarget) swap(next reflect.Value) {
+	t.v.Elem().Set(next.Elem())
+}
+
+func (t structTarget) check() error {
+	return nil
+}
+
+// WatchOption configures a Watcher.
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	// signals trigger a reload when received
+	signals []os.Signal
+	// interval between reloads, none when zero
+	interval time.Duration
+	logger   *log.Logger
+	// unmarshal are the options passed to Unmarshal
+	unmarshal []Option
+}
+
+// ReloadSignals sets the signals that make Run reload the configuration,
+// SIGHUP by default. Without signals, Run only polls.
+func ReloadSignals(signals ...os.Signal) WatchOption {
+	return func(o *watchOptions) {
+		o.signals = signals
+	}
+}
+
+// PollInterval makes Run also reload the configuration every d.
+func PollInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval = d
+	}
+}
+
+// WatchLogger sets the logger receiving the warnings and reload errors of a
+// Watcher, log.Default() by default.
+func WatchLogger(l *log.Logger) WatchOption {
+	return func(o *watchOptions) {
+		o.logger = l
+	}
+}
+
+// WatchUnmarshalOptions sets the options passed to Unmarshal on every load.
+func WatchUnmarshalOptions(opts ...Option) WatchOption {
+	return func(o *watchOptions) {
+		o.unmarshal = opts
+	}
+}
+
+// Watcher keeps a config struct up to date with a Source, reloading it on
+// SIGHUP or periodically. Every reload runs Unmarshal on a fresh EnvSet from
+// the Source and compares the result with the current config field by field.
+// Fields without an env tag keep the value they were given in code.
+//
+// Fields tagged with "reload=false" keep their startup value; a change to
+// them is logged as a warning and otherwise ignored.
+type Watcher struct {
+	// mu serialises reloads and guards target and callbacks
+	mu        sync.Mutex
+	target    reloadTarget
+	flags     *flag.FlagSet
+	source    Source
+	opts      watchOptions
+	callbacks []func(Change)
+}
+
+// NewWatcher loads source and unmarshals it, together with flags, into the
+// struct pointed to by v, then returns a Watcher updating v on every reload.
+// flags are parsed once and keep taking precedence over the Source. Fields
+// of v without an env tag, such as clients set up in code, keep their value.
+// If v is nil or not a pointer to a struct or a *Holder, NewWatcher returns
+// an ErrInvalidValue.
+//
+// The Watcher writes to a struct in place while holding its lock; code
+// reading it from other goroutines should copy the values it needs from the
+// OnChange callbacks. A *Holder is updated atomically instead.
+func NewWatcher(v interface{}, flags *flag.FlagSet, source Source, opts ...WatchOption) (*Watcher, error) {
+	target, ok := v.(reloadTarget)
+	if !ok {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+			return nil, ErrInvalidValue
+		}
+		target = structTarget{v: rv}
+	}
+	if err := target.check(); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		target: target,
+		flags:  flags,
+		source: source,
+		opts: watchOptions{
+			signals: []os.Signal{syscall.SIGHUP},
+			logger:  log.Default(),
+		},
+	}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+
+	next, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	target.swap(next)
+	return w, nil
+}
+
+// OnChange registers fn to be called with every field that changed on
+// reload, in field order, after the config has been updated. Callbacks run
+// while the Watcher is locked and must not call its methods.
+func (w *Watcher) OnChange(fn func(Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, fn)
+}
+
+// Reload loads the Source and updates the config. If loading or unmarshalling
+// fails, the config is left untouched and the error is returned.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, err := w.load()
+	if err != nil {
+		return err
+	}
+
+	changes, err := w.diff(w.target.value().Elem(), next.Elem(), "")
+	if err != nil {
+		return err
+	}
+
+	w.target.swap(next)
+	for _, c := range changes {
+		for _, fn := range w.callbacks {
+			fn(c)
+		}
+	}
+	return nil
+}
+
+// diff returns the changes from the struct cur to the struct next, with
+// field paths prefixed by prefix, then those of the structs their interface
+// fields point to. Changes to fields that are not reloadable are logged and
+// undone in next.
+func (w *Watcher) diff(cur, next reflect.Value, prefix string) ([]Change, error) {
+	p, err := planOf(cur.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, f := range p.fields {
+		// fields below a nil pointer to struct have a nil value
+		oldValue, oldErr := cur.FieldByIndexErr(f.Index)
+		newValue, newErr := next.FieldByIndexErr(f.Index)
+		var oldInterface, newInterface interface{}
+		if oldErr == nil {
+			oldInterface = oldValue.Interface()
+		}
+		if newErr == nil {
+			newInterface = newValue.Interface()
+		}
+		if reflect.DeepEqual(oldInterface, newInterface) {
+			continue
+		}
+		if f.Tag.NoReload {
+			w.opts.logger.Printf("env: %s changed but is not reloadable, restart to apply it", prefix+f.Path)
+			if oldErr == nil {
+				fieldByIndexAlloc(next, f.Index).Set(oldValue)
+			} else {
+				newValue.Set(reflect.Zero(f.Type))
+			}
+			continue
+		}
+		changes = append(changes, Change{
+			Path: prefix + f.Path,
+			Keys: f.Tag.Keys,
+			Old:  oldInterface,
+			New:  newInterface,
+		})
+	}
+
+	// next holds copies of the structs of cur, see reloadCopy
+	for _, iface := range p.ifaces {
+		oldValue, oldErr := cur.FieldByIndexErr(iface.Index)
+		newValue, newErr := next.FieldByIndexErr(iface.Index)
+		if oldErr != nil || newErr != nil || oldValue.IsNil() || newValue.IsNil() {
+			continue
+		}
+		oldStruct, newStruct := oldValue.Elem(), newValue.Elem()
+		if !isPtrToStruct(oldStruct.Type()) || oldStruct.IsNil() || oldStruct.Type() != newStruct.Type() {
+			continue
+		}
+		ifaceChanges, err := w.diff(oldStruct.Elem(), newStruct.Elem(), prefix+iface.Path+".")
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, ifaceChanges...)
+	}
+	return changes, nil
+}
+
+// load unmarshals the Source into a copy of the current config, see
+// reloadCopy.
+func (w *Watcher) load() (reflect.Value, error) {
+	es, err := w.source.Load()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	next, err := reloadCopy(w.target.value())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if err := Unmarshal(w.flags, es, next.Interface(), w.opts.unmarshal...); err != nil {
+		return reflect.Value{}, err
+	}
+	return next, nil
+}
+
+// reloadCopy returns a pointer to a copy of the struct pointed to by cur with
+// its tagged fields zeroed, so unmarshalling into it sets them as it would in
+// a new struct while the fields set in code are kept. The structs reached
+// through pointer and interface fields are copied too, so cur is left
+// untouched.
+func reloadCopy(cur reflect.Value) (reflect.Value, error) {
+	next := reflect.New(cur.Type().Elem())
+	next.Elem().Set(cur.Elem())
+	if err := resetCopy(next.Elem()); err != nil {
+		return reflect.Value{}, err
+	}
+	return next, nil
+}
+
+// resetCopy replaces the pointers to structs below the struct rv, a shallow
+// copy, by pointers to copies and zeroes the tagged fields, as reloadCopy
+// does.
+func resetCopy(rv reflect.Value) error {
+	p, err := planOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	// outer pointers come first, so inner ones are reached through copies
+	for _, ptr := range p.ptrs {
+		pv, err := rv.FieldByIndexErr(ptr.Index)
+		if err != nil || pv.IsNil() {
+			continue
+		}
+		c := reflect.New(ptr.Type)
+		c.Elem().Set(pv.Elem())
+		pv.Set(c)
+	}
+	for _, f := range p.fields {
+		// unexported fields are rejected by Unmarshal
+		if fv, err := rv.FieldByIndexErr(f.Index); err == nil && f.Exported {
+			fv.Set(reflect.Zero(f.Type))
+		}
+	}
+	for _, iface := range p.ifaces {
+		iv, err := rv.FieldByIndexErr(iface.Index)
+		if err != nil || iv.IsNil() {
+			continue
+		}
+		ev := iv.Elem()
+		if !isPtrToStruct(ev.Type()) || ev.IsNil() {
+			continue
+		}
+		c := reflect.New(ev.Type().Elem())
+		c.Elem().Set(ev.Elem())
+		if err := resetCopy(c.Elem()); err != nil {
+			return err
+		}
+		iv.Set(c)
+	}
+	return nil
+}
+
+// Run reloads the config on the reload signals and every poll interval until
+// ctx is done, then returns ctx.Err(). Reload errors are logged and the
+// previous config kept.
+func (w *Watcher) Run(ctx context.Context) error {
+	var signals chan os.Signal
+	if len(w.opts.signals) > 0 {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, w.opts.signals...)
+		defer signal.Stop(signals)
+	}
+
+	var tick <-chan time.Time
+	if w.opts.interval > 0 {
+		ticker := time.NewTicker(w.opts.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-signals:
+		case <-tick:
+		}
+		if err := w.Reload(); err != nil {
+			w.opts.logger.Printf("env: reload failed, keeping the previous config: %v", err)
+		}
+	}
+}
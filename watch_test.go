@@ -0,0 +1,337 @@
+// Copyright 2026 TubbyStubby.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type WatchStruct struct {
+	Token   string `env:"TOKEN"`
+	Limit   int    `env:"LIMIT,default=10"`
+	Listen  string `env:"LISTEN,default=:8080,reload=false"`
+	Backend struct {
+		Hosts []string `env:"BACKEND_HOSTS"`
+	}
+}
+
+// envSource is a Source whose EnvSet can be swapped by tests.
+type envSource struct {
+	mu  sync.Mutex
+	es  EnvSet
+	err error
+}
+
+func (s *envSource) set(es EnvSet, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.es, s.err = es, err
+}
+
+func (s *envSource) Load() (EnvSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	es := make(EnvSet, len(s.es))
+	for k, v := range s.es {
+		es[k] = v
+	}
+	return es, s.err
+}
+
+func TestWatcherReload(t *testing.T) {
+	t.Parallel()
+	var (
+		cfg     WatchStruct
+		logs    strings.Builder
+		changes []Change
+	)
+	source := &envSource{es: EnvSet{"TOKEN": "old", "BACKEND_HOSTS": "a|b"}}
+	w, err := NewWatcher(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), source, WatchLogger(log.New(&logs, "", 0)))
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	w.OnChange(func(c Change) {
+		changes = append(changes, c)
+	})
+
+	source.set(EnvSet{"TOKEN": "new", "LIMIT": "10", "LISTEN": ":9090", "BACKEND_HOSTS": "a|c"}, nil)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if cfg.Token != "new" || cfg.Listen != ":8080" || !reflect.DeepEqual(cfg.Backend.Hosts, []string{"a", "c"}) {
+		t.Errorf("Expected reloaded config but got '%+v'", cfg)
+	}
+	expected := []Change{
+		{Path: "Token", Keys: []string{"TOKEN"}, Old: "old", New: "new"},
+		{Path: "Backend.Hosts", Keys: []string{"BACKEND_HOSTS"}, Old: []string{"a", "b"}, New: []string{"a", "c"}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes to be '%+v' but got '%+v'", expected, changes)
+	}
+	if !strings.Contains(logs.String(), "Listen changed but is not reloadable") {
+		t.Errorf("Expected a warning for Listen but got '%s'", logs.String())
+	}
+}
+
+func TestWatcherReloadPointer(t *testing.T) {
+	t.Parallel()
+	type Audit struct {
+		Sink   string `env:"AUDIT_SINK"`
+		Secret string `env:"AUDIT_SECRET,reload=false"`
+	}
+	type PointerWatchStruct struct {
+		Audit *Audit
+	}
+	var (
+		cfg     PointerWatchStruct
+		changes []Change
+	)
+	source := &envSource{es: EnvSet{}}
+	w, err := NewWatcher(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), source, WatchLogger(log.New(io.Discard, "", 0)))
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	w.OnChange(func(c Change) {
+		changes = append(changes, c)
+	})
+
+	source.set(EnvSet{"AUDIT_SINK": "stdout", "AUDIT_SECRET": "s3cr3t"}, nil)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if cfg.Audit == nil || cfg.Audit.Sink != "stdout" || cfg.Audit.Secret != "" {
+		t.Errorf("Expected the audit sink without a secret but got '%+v'", cfg.Audit)
+	}
+	expected := []Change{{Path: "Audit.Sink", Keys: []string{"AUDIT_SINK"}, Old: nil, New: "stdout"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes to be '%+v' but got '%+v'", expected, changes)
+	}
+}
+
+func TestWatcherReloadKeepsUntagged(t *testing.T) {
+	t.Parallel()
+	type Backend struct {
+		Host   string `env:"BACKEND_HOST"`
+		Client string
+	}
+	type UntaggedWatchStruct struct {
+		Token   string `env:"TOKEN"`
+		Client  string
+		Backend *Backend
+	}
+	cfg := UntaggedWatchStruct{Token: "code", Client: "keep", Backend: &Backend{Client: "inner"}}
+	source := &envSource{es: EnvSet{"BACKEND_HOST": "a"}}
+	w, err := NewWatcher(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), source)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	backend := cfg.Backend
+
+	source.set(EnvSet{"TOKEN": "new", "BACKEND_HOST": "b"}, nil)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := UntaggedWatchStruct{Token: "new", Client: "keep", Backend: &Backend{Host: "b", Client: "inner"}}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Expected config to be '%+v' but got '%+v'", expected, cfg)
+	}
+	// the previous struct is replaced, not updated under its readers
+	if backend.Host != "a" {
+		t.Errorf("Expected the previous backend to be untouched but got '%+v'", backend)
+	}
+
+	source.set(EnvSet{}, nil)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if cfg.Token != "" || cfg.Backend.Host != "" || cfg.Client != "keep" {
+		t.Errorf("Expected removed keys to reset their fields but got '%+v'", cfg)
+	}
+}
+
+func TestNewWatcherKeepsUntagged(t *testing.T) {
+	t.Parallel()
+	type inner struct{ Name string }
+	type StartupStruct struct {
+		Token  string `env:"TOKEN"`
+		Client string
+		Any    interface{}
+	}
+	shared := &inner{Name: "x"}
+	cfg := StartupStruct{Client: "keep", Any: shared}
+	source := &envSource{es: EnvSet{"TOKEN": "t"}}
+	if _, err := NewWatcher(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), source); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := StartupStruct{Token: "t", Client: "keep", Any: shared}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Expected config to be '%+v' but got '%+v'", expected, cfg)
+	}
+}
+
+func TestWatcherReloadInterface(t *testing.T) {
+	t.Parallel()
+	type Plugin struct {
+		Token  string `env:"PLUGIN_TOKEN"`
+		Port   int    `env:"PLUGIN_PORT,reload=false"`
+		Client string
+	}
+	type IfaceWatchStruct struct {
+		Plugin interface{}
+	}
+	var (
+		cfg     = IfaceWatchStruct{Plugin: &Plugin{Client: "keep"}}
+		logs    strings.Builder
+		changes []Change
+	)
+	source := &envSource{es: EnvSet{"PLUGIN_TOKEN": "old", "PLUGIN_PORT": "1"}}
+	w, err := NewWatcher(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), source, WatchLogger(log.New(&logs, "", 0)))
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	w.OnChange(func(c Change) {
+		changes = append(changes, c)
+	})
+	previous := cfg.Plugin.(*Plugin)
+
+	source.set(EnvSet{"PLUGIN_TOKEN": "new", "PLUGIN_PORT": "2"}, nil)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	expected := &Plugin{Token: "new", Port: 1, Client: "keep"}
+	if !reflect.DeepEqual(cfg.Plugin, expected) {
+		t.Errorf("Expected plugin to be '%+v' but got '%+v'", expected, cfg.Plugin)
+	}
+	if previous.Token != "old" {
+		t.Errorf("Expected the previous plugin to be untouched but got '%+v'", previous)
+	}
+	expectedChanges := []Change{{Path: "Plugin.Token", Keys: []string{"PLUGIN_TOKEN"}, Old: "old", New: "new"}}
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("Expected changes to be '%+v' but got '%+v'", expectedChanges, changes)
+	}
+	if !strings.Contains(logs.String(), "Plugin.Port changed but is not reloadable") {
+		t.Errorf("Expected a warning for Plugin.Port but got '%s'", logs.String())
+	}
+}
+
+func TestWatcherReloadError(t *testing.T) {
+	t.Parallel()
+	var cfg WatchStruct
+	source := &envSource{es: EnvSet{"TOKEN": "old"}}
+	w, err := NewWatcher(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), source)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	source.set(EnvSet{"TOKEN": "new", "LIMIT": "lots"}, nil)
+	if err := w.Reload(); err == nil {
+		t.Errorf("Expected an error for LIMIT")
+	}
+	errLoad := errors.New("load failed")
+	source.set(nil, errLoad)
+	if err := w.Reload(); !errors.Is(err, errLoad) {
+		t.Errorf("Expected error '%s' but got '%v'", errLoad, err)
+	}
+
+	if cfg.Token != "old" || cfg.Limit != 10 {
+		t.Errorf("Expected the previous config to be kept but got '%+v'", cfg)
+	}
+}
+
+func TestWatcherFlagsWin(t *testing.T) {
+	t.Parallel()
+	var cfg WatchStruct
+	flags, err := RegisterFlags(&cfg)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if err := flags.Parse([]string{"-limit", "3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &envSource{es: EnvSet{"LIMIT": "5"}}
+	w, err := NewWatcher(&cfg, flags, source)
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	source.set(EnvSet{"LIMIT": "7"}, nil)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	if cfg.Limit != 3 {
+		t.Errorf("Expected LIMIT from the flag to be 3 but got %d", cfg.Limit)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(path, []byte("TOKEN=old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg WatchStruct
+	w, err := NewWatcher(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), FileSource(path),
+		ReloadSignals(), PollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+	reloaded := make(chan Change, 1)
+	w.OnChange(func(c Change) {
+		select {
+		case reloaded <- c:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	// replace the file atomically so the poller never reads it half written
+	if err := os.WriteFile(path+".tmp", []byte("TOKEN=new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-reloaded:
+		if c.New != "new" {
+			t.Errorf("Expected TOKEN to change to 'new' but got '%v'", c.New)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected the file change to be picked up")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error 'context.Canceled' but got '%v'", err)
+	}
+}